@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -36,8 +37,16 @@ func completer(in prompt.Document) []prompt.Choice {
 	return prompt.FilterHasPrefix(s, in.GetWordBeforeCursor(), true)
 }
 
-func executor(text string) {
+func executor(text string) prompt.ExecResult {
+	switch strings.TrimSpace(text) {
+	case "exit", "quit":
+		return prompt.ExecResult{Exit: true}
+	case "fail":
+		return prompt.ExecResult{Err: errors.New("failed, as requested")}
+	}
 	fmt.Printf("\x1b[34myou entered:\x1b[m \x1b[1m%s\x1b[m\n", text)
+	// like bash's HISTCONTROL=ignorespace
+	return prompt.ExecResult{NoHistory: strings.HasPrefix(text, " ")}
 }
 
 type MLOutdentMode int
@@ -131,8 +140,9 @@ func main() {
 	}
 
 	p := prompt.New(
-		executor,
+		nil,
 		completer,
+		prompt.OptionResultExecutor(executor),
 		prompt.OptionCompleteAsYouType(false),
 		prompt.OptionLivePrefix(func(_ *prompt.Document, row prompt.Row) (prefix string, active bool) {
 			if row == 0 {
@@ -142,7 +152,7 @@ func main() {
 			}
 			return
 		}),
-		prompt.OptionBindKey(prompt.KeyBind{prompt.KeyEnter, e.on_enter}),
+		prompt.OptionBindKey(prompt.KeyBind{Key: prompt.KeyEnter, Fn: e.on_enter}),
 		prompt.OptionDescriptionBGColor(prompt.NewRGB(40, 25, 50)),
 		prompt.OptionDescriptionTextColor(prompt.NewRGB(120, 120, 40)),
 	)
@@ -0,0 +1,73 @@
+// Command replay feeds the input of a session recorded with prompt.OptionRecord back through a prompt.
+//
+//	replay [-speed 1] [-screen] session.cast
+//
+// With -screen, the prompt runs on a headless terminal of the recorded size,
+// and the final screen is printed; that's deterministic, so it can be compared with a bug report.
+// The prompt has no completions; to replay with those of an application,
+// use prompt.NewReplayParser with prompt.OptionParser in the application.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/prompttest"
+)
+
+func main() {
+	speed := flag.Float64("speed", 1, "replay speed; 0 replays without delays")
+	screen := flag.Bool("screen", false, "replay on a headless terminal and print the final screen")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: replay [-speed 1] [-screen] session.cast")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	in, err := prompt.NewReplayParser(f, *speed)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var executed []string
+	executor := func(s string) {
+		if *screen {
+			executed = append(executed, s)
+		} else {
+			fmt.Println("Your input: " + s)
+		}
+	}
+	completer := func(prompt.Document) []prompt.Choice { return nil }
+
+	opts := []prompt.Option{prompt.OptionParser(in)}
+	var term *prompttest.Terminal
+	if *screen {
+		ws := in.GetWinSize()
+		term = prompttest.NewTerminal(int(ws.Col), int(ws.Row))
+		opts = append(opts, prompt.OptionWriter(prompt.NewStreamWriter(term.Screen)))
+	}
+	p := prompt.New(executor, completer, opts...)
+
+	// the prompt returns ErrEOF once it has handled (and rendered) the last input
+	if _, err := p.RunContext(context.Background()); err != nil && err != prompt.ErrEOF {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *screen {
+		fmt.Println(term.Screen.Snapshot())
+		for _, s := range executed {
+			fmt.Printf("executed: %q\n", s)
+		}
+	}
+}
@@ -11,6 +11,7 @@ type StateFlags struct {
 	endEdit       bool
 	eof           bool
 	translatedKey KeyCode
+	toggled       bool // toggle_beginning_of_line ran (see Prompt.feedKey)
 }
 
 // Buffer emulates the console buffer.
@@ -19,6 +20,7 @@ type Buffer struct {
 	textLock        *sync.RWMutex
 	cursor          Index // absolute character index into 'text'
 	preferredColumn Index // preferred column for the next up/down movement.
+	toggleIndex     Index // cursor index to return to by toggle_beginning_of_line, -1 if none
 	flags           StateFlags
 
 	cacheDocument *Document
@@ -27,7 +29,8 @@ type Buffer struct {
 // NewBuffer is constructor of Buffer struct.
 func NewBuffer() *Buffer {
 	return &Buffer{
-		textLock: &sync.RWMutex{},
+		textLock:    &sync.RWMutex{},
+		toggleIndex: -1,
 	}
 }
 
@@ -158,6 +161,18 @@ func (b *Buffer) setCursorIndex(p int) {
 	}
 }
 
+// SetCursorIndex moves the cursor to the absolute character index 'index'.
+func (b *Buffer) SetCursorIndex(index Index) {
+	b.textLock.RLock()
+	defer b.textLock.RUnlock()
+
+	if n := len([]rune(b.text)); index > n {
+		index = n
+	}
+	b.setCursorIndex(index)
+	b.preferredColumn = b.document().CursorColumnIndex()
+}
+
 func (b *Buffer) setDocument(d *Document) {
 	b.cacheDocument = d
 	b.setCursorIndex(d.cursor) // Call before setText because setText check the relation between cursor and line length.
@@ -0,0 +1,251 @@
+package prompt
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Ansi256Color is a color of the 256-color palette:
+// 0-15 are the ANSI colors, 16-231 a 6x6x6 color cube and 232-255 shades of gray.
+type Ansi256Color uint8
+
+func (Ansi256Color) IsTrueColor() bool {
+	return false
+}
+
+// ColorDepth is the number of colors a terminal can display.
+type ColorDepth int
+
+const (
+	// ColorDepthNone is no colors at all (e.g. with NO_COLOR); text attributes like bold are still used.
+	ColorDepthNone ColorDepth = iota
+	// ColorDepth16 is the 16 ANSI colors (AnsiColor).
+	ColorDepth16
+	// ColorDepth256 is the 256-color palette (Ansi256Color).
+	ColorDepth256
+	// ColorDepthTrueColor is 24-bit colors (RGBColor).
+	ColorDepthTrueColor
+)
+
+// DetectColorDepth returns the color depth of the terminal, from the environment:
+// NO_COLOR (https://no-color.org), COLORTERM, TERM and its terminfo entry.
+func DetectColorDepth() ColorDepth {
+	return detectColorDepth(os.Getenv, terminfoColors)
+}
+
+func detectColorDepth(getenv func(string) string, terminfo func(term string) (int, bool)) ColorDepth {
+	if getenv("NO_COLOR") != "" {
+		return ColorDepthNone
+	}
+	// https://gist.github.com/XVilka/8346728#detection
+	switch getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColorDepthTrueColor
+	}
+
+	term := getenv("TERM")
+	switch {
+	case term == "dumb":
+		return ColorDepthNone
+	case strings.HasSuffix(term, "-direct") || strings.HasSuffix(term, "-truecolor"):
+		return ColorDepthTrueColor
+	case strings.Contains(term, "256color"):
+		return ColorDepth256
+	}
+	if n, ok := terminfo(term); ok {
+		switch {
+		case n >= 1<<24:
+			return ColorDepthTrueColor
+		case n >= 256:
+			return ColorDepth256
+		case n < 8:
+			return ColorDepthNone
+		}
+		return ColorDepth16
+	}
+	if runtime.GOOS == "windows" && getenv("WT_SESSION") != "" { // Windows Terminal
+		return ColorDepthTrueColor
+	}
+	return ColorDepth16
+}
+
+// terminfoColors returns the number of colors ("max_colors") in the terminfo entry of 'term'.
+func terminfoColors(term string) (int, bool) {
+	if term == "" || strings.ContainsAny(term, `/\`) {
+		return 0, false
+	}
+	var dirs []string
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	dirs = append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo")
+
+	for _, dir := range dirs {
+		// entries are in a directory named after their first letter, or its hex code (e.g. on macOS)
+		for _, sub := range []string{term[:1], hexByte(term[0])} {
+			if b, err := ioutil.ReadFile(filepath.Join(dir, sub, term)); err == nil {
+				return parseTerminfoColors(b)
+			}
+		}
+	}
+	return 0, false
+}
+
+func hexByte(b byte) string {
+	const digits = "0123456789abcdef"
+	return string([]byte{digits[b>>4], digits[b&0xf]})
+}
+
+// parseTerminfoColors returns the "max_colors" number of a compiled terminfo entry (see term(5)).
+func parseTerminfoColors(b []byte) (int, bool) {
+	const maxColors = 13 // the index of max_colors in the numbers section
+	if len(b) < 12 {
+		return 0, false
+	}
+	header := make([]int, 6)
+	for i := range header {
+		header[i] = int(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	numberSize := 2
+	switch header[0] {
+	case 0432:
+	case 01036: // the extended format, with 32-bit numbers
+		numberSize = 4
+	default:
+		return 0, false
+	}
+	namesSize, boolCount, numberCount := header[1], header[2], header[3]
+	if numberCount <= maxColors {
+		return 0, false
+	}
+	offset := 12 + namesSize + boolCount
+	if offset%2 == 1 { // the numbers are aligned
+		offset++
+	}
+	offset += maxColors * numberSize
+	if offset+numberSize > len(b) {
+		return 0, false
+	}
+	var n int
+	if numberSize == 2 {
+		n = int(int16(binary.LittleEndian.Uint16(b[offset:])))
+	} else {
+		n = int(int32(binary.LittleEndian.Uint32(b[offset:])))
+	}
+	return n, n >= 0
+}
+
+// ansiPalette is the RGB value of the 16 ANSI colors (as in xterm), in the order of the 256-color palette.
+var ansiPalette = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the values of each component in the 6x6x6 color cube of the 256-color palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// rgb returns the RGB value of a 256-color palette entry.
+func (c Ansi256Color) rgb() [3]uint8 {
+	switch {
+	case c < 16:
+		return ansiPalette[c]
+	case c < 232:
+		i := int(c) - 16
+		return [3]uint8{cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]}
+	default:
+		v := uint8(8 + 10*(int(c)-232))
+		return [3]uint8{v, v, v}
+	}
+}
+
+// Convert returns 'c' as it's displayed with the color depth: colors the terminal can't display
+// are replaced with the nearest one it can (or DefaultColor for ColorDepthNone).
+func (d ColorDepth) Convert(c Color) Color {
+	switch c := c.(type) {
+	case RGBColor:
+		switch d {
+		case ColorDepthTrueColor:
+			return c
+		case ColorDepth256:
+			return nearest256([3]uint8{c.Red, c.Green, c.Blue})
+		case ColorDepth16:
+			return nearest16([3]uint8{c.Red, c.Green, c.Blue})
+		}
+		return DefaultColor
+	case Ansi256Color:
+		switch {
+		case d >= ColorDepth256:
+			return c
+		case d == ColorDepth16 && c < 16:
+			return Black + AnsiColor(c)
+		case d == ColorDepth16:
+			return nearest16(c.rgb())
+		}
+		return DefaultColor
+	case nil:
+		return nil
+	}
+	if d == ColorDepthNone {
+		return DefaultColor
+	}
+	return c
+}
+
+func colorDistance(a, b [3]uint8) int {
+	var d int
+	for i := range a {
+		x := int(a[i]) - int(b[i])
+		d += x * x
+	}
+	return d
+}
+
+// nearest16 returns the ANSI color nearest to 'rgb'.
+func nearest16(rgb [3]uint8) AnsiColor {
+	best := 0
+	for i := range ansiPalette {
+		if colorDistance(rgb, ansiPalette[i]) < colorDistance(rgb, ansiPalette[best]) {
+			best = i
+		}
+	}
+	return Black + AnsiColor(best)
+}
+
+// nearest256 returns the entry of the color cube or the gray ramp of the 256-color palette nearest to 'rgb'.
+func nearest256(rgb [3]uint8) Ansi256Color {
+	var cube [3]int
+	for i, v := range rgb {
+		for j := range cubeLevels {
+			if absDiff(v, cubeLevels[j]) < absDiff(v, cubeLevels[cube[i]]) {
+				cube[i] = j
+			}
+		}
+	}
+	c := Ansi256Color(16 + 36*cube[0] + 6*cube[1] + cube[2])
+
+	avg := (int(rgb[0]) + int(rgb[1]) + int(rgb[2])) / 3
+	gi := (avg - 3) / 10
+	if gi < 0 {
+		gi = 0
+	} else if gi > 23 {
+		gi = 23
+	}
+	if gray := Ansi256Color(232 + gi); colorDistance(rgb, gray.rgb()) < colorDistance(rgb, c.rgb()) {
+		return gray
+	}
+	return c
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
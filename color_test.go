@@ -0,0 +1,171 @@
+package prompt
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestDetectColorDepth(t *testing.T) {
+	terminfo := func(term string) (int, bool) {
+		n, ok := map[string]int{"xterm": 8, "vt100": 0, "kitty": 256, "alacritty-direct": 1 << 24}[term]
+		return n, ok
+	}
+	scenarioTable := []struct {
+		env      map[string]string
+		expected ColorDepth
+	}{
+		{env: map[string]string{}, expected: ColorDepth16},
+		{env: map[string]string{"TERM": "xterm"}, expected: ColorDepth16},
+		{env: map[string]string{"TERM": "vt100"}, expected: ColorDepthNone},
+		{env: map[string]string{"TERM": "dumb"}, expected: ColorDepthNone},
+		{env: map[string]string{"TERM": "xterm-256color"}, expected: ColorDepth256},
+		{env: map[string]string{"TERM": "kitty"}, expected: ColorDepth256},
+		{env: map[string]string{"TERM": "xterm-direct"}, expected: ColorDepthTrueColor},
+		{env: map[string]string{"TERM": "alacritty"}, expected: ColorDepth16},
+		{env: map[string]string{"TERM": "xterm", "COLORTERM": "truecolor"}, expected: ColorDepthTrueColor},
+		{env: map[string]string{"TERM": "xterm-256color", "COLORTERM": "24bit"}, expected: ColorDepthTrueColor},
+		{env: map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor", "NO_COLOR": "1"}, expected: ColorDepthNone},
+	}
+
+	for _, s := range scenarioTable {
+		getenv := func(name string) string { return s.env[name] }
+		if d := detectColorDepth(getenv, terminfo); d != s.expected {
+			t.Errorf("%v: Should be %v, but got %v", s.env, s.expected, d)
+		}
+	}
+}
+
+// terminfoEntry returns a compiled terminfo entry with the given numbers.
+func terminfoEntry(magic int, names string, bools int, numbers []int) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 12)
+	for i, v := range []int{magic, len(names) + 1, bools, len(numbers), 0, 0} {
+		le.PutUint16(b[2*i:], uint16(v))
+	}
+	b = append(b, names...)
+	b = append(b, 0)
+	b = append(b, make([]byte, bools)...)
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	for _, n := range numbers {
+		if magic == 0432 {
+			b = append(b, 0, 0)
+			le.PutUint16(b[len(b)-2:], uint16(int16(n)))
+		} else {
+			b = append(b, 0, 0, 0, 0)
+			le.PutUint32(b[len(b)-4:], uint32(int32(n)))
+		}
+	}
+	return b
+}
+
+func TestParseTerminfoColors(t *testing.T) {
+	numbers := func(colors int) []int {
+		n := make([]int, 15)
+		for i := range n {
+			n[i] = -1
+		}
+		n[13] = colors
+		return n
+	}
+	scenarioTable := []struct {
+		entry    []byte
+		expected int
+		ok       bool
+	}{
+		{entry: terminfoEntry(0432, "xterm|xterm terminal emulator", 38, numbers(8)), expected: 8, ok: true},
+		{entry: terminfoEntry(0432, "xterm-256color|xterm with 256 colors", 37, numbers(256)), expected: 256, ok: true},
+		{entry: terminfoEntry(01036, "xterm-direct|xterm with direct-color indexing", 37, numbers(1<<24)), expected: 1 << 24, ok: true},
+		{entry: terminfoEntry(0432, "vt100|dec vt100", 38, numbers(-1)), expected: -1, ok: false},
+		{entry: terminfoEntry(0432, "short", 2, []int{80, 24}), ok: false},
+		{entry: terminfoEntry(0x1234, "bad|bad magic", 2, numbers(8)), ok: false},
+		{entry: []byte{0x1a, 0x01}, ok: false},
+	}
+
+	for _, s := range scenarioTable {
+		n, ok := parseTerminfoColors(s.entry)
+		if ok != s.ok || (ok && n != s.expected) {
+			t.Errorf("Should be %v (%v), but got %v (%v)", s.expected, s.ok, n, ok)
+		}
+	}
+}
+
+func TestColorDepthConvert(t *testing.T) {
+	scenarioTable := []struct {
+		depth    ColorDepth
+		color    Color
+		expected Color
+	}{
+		{depth: ColorDepthTrueColor, color: NewRGB(1, 2, 3), expected: NewRGB(1, 2, 3)},
+		{depth: ColorDepth256, color: NewRGB(255, 0, 0), expected: Ansi256Color(196)},
+		{depth: ColorDepth256, color: NewRGB(0x26, 0x8b, 0xd2), expected: Ansi256Color(32)},
+		{depth: ColorDepth256, color: NewRGB(128, 128, 128), expected: Ansi256Color(244)},
+		{depth: ColorDepth256, color: NewRGB(0, 0, 0), expected: Ansi256Color(16)},
+		{depth: ColorDepth256, color: Ansi256Color(99), expected: Ansi256Color(99)},
+		{depth: ColorDepth256, color: Blue, expected: Blue},
+		{depth: ColorDepth16, color: NewRGB(250, 10, 10), expected: BrightRed},
+		{depth: ColorDepth16, color: NewRGB(10, 10, 10), expected: Black},
+		{depth: ColorDepth16, color: NewRGB(0x26, 0x8b, 0xd2), expected: Cyan},
+		{depth: ColorDepth16, color: Ansi256Color(3), expected: Yellow},
+		{depth: ColorDepth16, color: Ansi256Color(196), expected: BrightRed},
+		{depth: ColorDepth16, color: Ansi256Color(250), expected: Gray},
+		{depth: ColorDepth16, color: Cyan, expected: Cyan},
+		{depth: ColorDepthNone, color: Cyan, expected: DefaultColor},
+		{depth: ColorDepthNone, color: NewRGB(1, 2, 3), expected: DefaultColor},
+		{depth: ColorDepthNone, color: nil, expected: nil},
+	}
+
+	for _, s := range scenarioTable {
+		if c := s.depth.Convert(s.color); c != s.expected {
+			t.Errorf("%v with depth %v: Should be %#v, but got %#v", s.color, s.depth, s.expected, c)
+		}
+	}
+}
+
+func TestColorDepthConvertRGB(t *testing.T) {
+	scenarioTable := []struct {
+		rgb  [3]uint8
+		c256 Ansi256Color
+		c16  AnsiColor
+	}{
+		// the color cube
+		{rgb: [3]uint8{0, 0, 0}, c256: 16, c16: Black},
+		{rgb: [3]uint8{255, 255, 255}, c256: 231, c16: White},
+		{rgb: [3]uint8{255, 0, 0}, c256: 196, c16: BrightRed},
+		{rgb: [3]uint8{205, 0, 0}, c256: 160, c16: Red},
+		{rgb: [3]uint8{255, 135, 0}, c256: 208, c16: Yellow},
+		{rgb: [3]uint8{0x87, 0xaf, 0xff}, c256: 111, c16: BrightBlue},
+		{rgb: [3]uint8{0, 100, 0}, c256: 22, c16: Black},
+		{rgb: [3]uint8{0, 120, 0}, c256: 28, c16: Green},
+		{rgb: [3]uint8{96, 95, 94}, c256: 59, c16: BrightBlack},
+		// the gray ramp
+		{rgb: [3]uint8{8, 8, 8}, c256: 232, c16: Black},
+		{rgb: [3]uint8{18, 18, 18}, c256: 233, c16: Black},
+		{rgb: [3]uint8{100, 100, 100}, c256: 241, c16: BrightBlack},
+		{rgb: [3]uint8{128, 128, 128}, c256: 244, c16: BrightBlack},
+		{rgb: [3]uint8{200, 201, 199}, c256: 251, c16: Gray},
+		{rgb: [3]uint8{238, 238, 238}, c256: 255, c16: Gray},
+	}
+
+	for _, s := range scenarioTable {
+		c := NewRGB(s.rgb[0], s.rgb[1], s.rgb[2])
+		for _, e := range []struct {
+			depth    ColorDepth
+			expected Color
+		}{
+			{depth: ColorDepthTrueColor, expected: c},
+			{depth: ColorDepth256, expected: s.c256},
+			{depth: ColorDepth16, expected: s.c16},
+			{depth: ColorDepthNone, expected: DefaultColor},
+		} {
+			if actual := e.depth.Convert(c); actual != e.expected {
+				t.Errorf("%v with depth %v: Should be %#v, but got %#v", s.rgb, e.depth, e.expected, actual)
+			}
+		}
+		// a 256-color palette entry is displayed as the nearest ANSI color too
+		if actual := ColorDepth16.Convert(s.c256); actual != s.c16 {
+			t.Errorf("%v with depth %v: Should be %#v, but got %#v", s.c256, ColorDepth16, s.c16, actual)
+		}
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"path/filepath"
 	"runtime"
 
-	prompt "github.com/c-bata/go-prompt"
-	"github.com/c-bata/go-prompt/internal/debug"
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/internal/debug"
 )
 
 var (
@@ -22,7 +22,7 @@ var (
 type FilePathCompleter struct {
 	Filter        func(fi os.FileInfo) bool
 	IgnoreCase    bool
-	fileListCache map[string][]prompt.Suggest
+	fileListCache map[string][]prompt.Choice
 }
 
 func cleanFilePath(path string) (dir, base string, err error) {
@@ -54,9 +54,9 @@ func cleanFilePath(path string) (dir, base string, err error) {
 }
 
 // Complete returns suggestions from your local file system.
-func (c *FilePathCompleter) Complete(d prompt.Document) []prompt.Suggest {
+func (c *FilePathCompleter) Complete(d prompt.Document) []prompt.Choice {
 	if c.fileListCache == nil {
-		c.fileListCache = make(map[string][]prompt.Suggest, 4)
+		c.fileListCache = make(map[string][]prompt.Choice, 4)
 	}
 
 	path := d.GetWordBeforeCursor()
@@ -78,12 +78,12 @@ func (c *FilePathCompleter) Complete(d prompt.Document) []prompt.Suggest {
 		return nil
 	}
 
-	suggests := make([]prompt.Suggest, 0, len(files))
+	suggests := make([]prompt.Choice, 0, len(files))
 	for _, f := range files {
 		if c.Filter != nil && !c.Filter(f) {
 			continue
 		}
-		suggests = append(suggests, prompt.Suggest{Text: f.Name()})
+		suggests = append(suggests, prompt.Choice{Text: f.Name()})
 	}
 	c.fileListCache[dir] = suggests
 	return prompt.FilterHasPrefix(suggests, base, c.IgnoreCase)
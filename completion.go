@@ -134,6 +134,26 @@ func (c *CompletionManager) Previous() {
 	c.update()
 }
 
+// Select selects the choice at 'index' (-1 to select nothing).
+func (c *CompletionManager) Select(index int) {
+	if index < -1 || index >= len(c.choices) {
+		return
+	}
+	c.selected = index
+	c.update()
+}
+
+// Scroll scrolls the visible choices by 'delta' rows, without changing the selection.
+func (c *CompletionManager) Scroll(delta int) {
+	c.verticalScroll += delta
+	if max := len(c.choices) - c.maxVisibleChoices; c.verticalScroll > max {
+		c.verticalScroll = max
+	}
+	if c.verticalScroll < 0 {
+		c.verticalScroll = 0
+	}
+}
+
 // Completing returns whether some suggestion is currently selected.
 func (c *CompletionManager) Completing() bool {
 	return c.selected != -1
@@ -154,6 +174,13 @@ func (c *CompletionManager) update() {
 	} else if c.selected < -1 {
 		c.selected = len(c.choices) - 1
 		c.verticalScroll = len(c.choices) - visible
+	} else if c.selected >= 0 {
+		// keep the selected choice visible (the view might have been scrolled)
+		if c.selected < c.verticalScroll {
+			c.verticalScroll = c.selected
+		} else if c.selected >= c.verticalScroll+visible {
+			c.verticalScroll = c.selected - visible + 1
+		}
 	}
 }
 
@@ -250,7 +277,7 @@ func formatTexts(texts []string, maxWidth Column, prefix, suffix string) (format
 	// find widest text
 	var widest Column
 	for _, text := range texts {
-		w := Column(runewidth.StringWidth(text))
+		w := textWidth(text)
 		if w > widest {
 			widest = w
 		}
@@ -269,13 +296,14 @@ func formatTexts(texts []string, maxWidth Column, prefix, suffix string) (format
 
 	formatted = make([]string, len(texts))
 	for idx, text := range texts {
-		w := Column(runewidth.StringWidth(text))
+		w := textWidth(text) // escape sequences (e.g. hyperlinks) take no width
 		if w > widthLimit {
-			text = runewidth.Truncate(text, int(widthLimit), ellipsis)
-			// runewidth.Truncate("您好xxx您好xxx", 11, "...") will "您好xxx..." (i.e. width 10),
+			text = truncateText(text, widthLimit, ellipsis)
+			// truncateText("您好xxx您好xxx", 11, "...") will "您好xxx..." (i.e. width 10),
 			// so we need to recalculate the width (and pad it at the end if necessary)
+			w = textWidth(text)
 		}
-		text = runewidth.FillRight(text, int(widthLimit))
+		text += strings.Repeat(" ", int(widthLimit-w))
 
 		formatted[idx] = prefix + text + suffix
 		//fmt.Fprintf(os.Stderr, "-'%s' (%d)\n", formatted[idx], runewidth.StringWidth(formatted[idx]))
@@ -7,18 +7,18 @@ import (
 
 func TestFormatShortSuggestion(t *testing.T) {
 	var scenarioTable = []struct {
-		in       []Suggest
-		expected []Suggest
-		max      int
-		exWidth  int
+		in       []Choice
+		expected []Choice
+		max      Column
+		exWidth  Column
 	}{
 		{
-			in: []Suggest{
+			in: []Choice{
 				{Text: "foo"},
 				{Text: "bar"},
 				{Text: "fuga"},
 			},
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: " foo  "},
 				{Text: " bar  "},
 				{Text: " fuga "},
@@ -27,45 +27,45 @@ func TestFormatShortSuggestion(t *testing.T) {
 			exWidth: 6,
 		},
 		{
-			in: []Suggest{
+			in: []Choice{
 				{Text: "apple", Description: "This is apple."},
 				{Text: "banana", Description: "This is banana."},
 				{Text: "coconut", Description: "This is coconut."},
 			},
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: " apple   ", Description: " This is apple.   "},
 				{Text: " banana  ", Description: " This is banana.  "},
 				{Text: " coconut ", Description: " This is coconut. "},
 			},
 			max:     100,
-			exWidth: len(" apple   " + " This is apple.   "),
+			exWidth: textWidth(" apple   " + " This is apple.   "),
 		},
 		{
-			in: []Suggest{
-				{Text: "This is apple."},
-				{Text: "This is banana."},
-				{Text: "This is coconut."},
-			},
-			expected: []Suggest{
-				{Text: " Thi... "},
-				{Text: " Thi... "},
-				{Text: " Thi... "},
-			},
-			max:     8,
-			exWidth: 8,
+			in: []Choice{
+				{Text: "Apple pie with cream"},
+				{Text: "Banana split with cream"},
+				{Text: "Coconut cake with cream"},
+			},
+			expected: []Choice{
+				{Text: " Apple pie with cr… "},
+				{Text: " Banana split with… "},
+				{Text: " Coconut cake with… "},
+			},
+			max:     20,
+			exWidth: 20,
 		},
 		{
-			in: []Suggest{
+			in: []Choice{
 				{Text: "This is apple."},
 				{Text: "This is banana."},
 				{Text: "This is coconut."},
 			},
-			expected: []Suggest{},
+			expected: nil,
 			max:      3,
 			exWidth:  0,
 		},
 		{
-			in: []Suggest{
+			in: []Choice{
 				{Text: "--all-namespaces", Description: "-------------------------------------------------------------------------------------------------------------------------------------------"},
 				{Text: "--allow-missing-template-keys", Description: "-----------------------------------------------------------------------------------------------------------------------------------------------"},
 				{Text: "--export", Description: "----------------------------------------------------------------------------------------------------------"},
@@ -73,19 +73,19 @@ func TestFormatShortSuggestion(t *testing.T) {
 				{Text: "--filename", Description: "-----------------------------------------------------------------------------------"},
 				{Text: "--include-extended-apis", Description: "------------------------------------------------------------------------------------"},
 			},
-			expected: []Suggest{
-				{Text: " --all-namespaces              ", Description: " --------------... "},
-				{Text: " --allow-missing-template-keys ", Description: " --------------... "},
-				{Text: " --export                      ", Description: " --------------... "},
-				{Text: " -f                            ", Description: " --------------... "},
-				{Text: " --filename                    ", Description: " --------------... "},
-				{Text: " --include-extended-apis       ", Description: " --------------... "},
+			expected: []Choice{
+				{Text: " --all-namespaces              ", Description: " ----------------… "},
+				{Text: " --allow-missing-template-keys ", Description: " ----------------… "},
+				{Text: " --export                      ", Description: " ----------------… "},
+				{Text: " -f                            ", Description: " ----------------… "},
+				{Text: " --filename                    ", Description: " ----------------… "},
+				{Text: " --include-extended-apis       ", Description: " ----------------… "},
 			},
 			max:     50,
-			exWidth: len(" --include-extended-apis       " + " ---------------..."),
+			exWidth: textWidth(" --include-extended-apis       " + " ----------------… "),
 		},
 		{
-			in: []Suggest{
+			in: []Choice{
 				{Text: "--all-namespaces", Description: "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace."},
 				{Text: "--allow-missing-template-keys", Description: "If true, ignore any errors in templates when a field or map key is missing in the template. Only applies to golang and jsonpath output formats."},
 				{Text: "--export", Description: "If true, use 'export' for the resources.  Exported resources are stripped of cluster-specific information."},
@@ -93,7 +93,7 @@ func TestFormatShortSuggestion(t *testing.T) {
 				{Text: "--filename", Description: "Filename, directory, or URL to files identifying the resource to get from a server."},
 				{Text: "--include-extended-apis", Description: "If true, include definitions of new APIs via calls to the API server. [default true]"},
 			},
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: " --all-namespaces              ", Description: " If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.     "},
 				{Text: " --allow-missing-template-keys ", Description: " If true, ignore any errors in templates when a field or map key is missing in the template. Only applies to golang and jsonpath output formats. "},
 				{Text: " --export                      ", Description: " If true, use 'export' for the resources.  Exported resources are stripped of cluster-specific information.                                      "},
@@ -102,12 +102,12 @@ func TestFormatShortSuggestion(t *testing.T) {
 				{Text: " --include-extended-apis       ", Description: " If true, include definitions of new APIs via calls to the API server. [default true]                                                            "},
 			},
 			max:     500,
-			exWidth: len(" --include-extended-apis       " + " If true, include definitions of new APIs via calls to the API server. [default true]                                                            "),
+			exWidth: textWidth(" --include-extended-apis       " + " If true, include definitions of new APIs via calls to the API server. [default true]                                                            "),
 		},
 	}
 
 	for i, s := range scenarioTable {
-		actual, width := formatSuggestions(s.in, s.max)
+		actual, width := formatChoices(s.in, s.max)
 		if width != s.exWidth {
 			t.Errorf("[scenario %d] Want %d but got %d\n", i, s.exWidth, width)
 		}
@@ -121,20 +121,17 @@ func TestFormatText(t *testing.T) {
 	var scenarioTable = []struct {
 		in       []string
 		expected []string
-		max      int
-		exWidth  int
+		max      Column
+		exWidth  Column
 	}{
 		{
 			in: []string{
 				"",
 				"",
 			},
-			expected: []string{
-				"",
-				"",
-			},
-			max:     10,
-			exWidth: 0,
+			expected: nil,
+			max:      10,
+			exWidth:  0,
 		},
 		{
 			in: []string{
@@ -142,13 +139,9 @@ func TestFormatText(t *testing.T) {
 				"banana",
 				"coconut",
 			},
-			expected: []string{
-				"",
-				"",
-				"",
-			},
-			max:     2,
-			exWidth: 0,
+			expected: nil,
+			max:      2,
+			exWidth:  0,
 		},
 		{
 			in: []string{
@@ -156,13 +149,9 @@ func TestFormatText(t *testing.T) {
 				"banana",
 				"coconut",
 			},
-			expected: []string{
-				"",
-				"",
-				"",
-			},
-			max:     len(" " + " " + shortenSuffix),
-			exWidth: 0,
+			expected: nil,
+			max:      textWidth(" " + " " + ellipsis),
+			exWidth:  0,
 		},
 		{
 			in: []string{
@@ -176,7 +165,7 @@ func TestFormatText(t *testing.T) {
 				" coconut ",
 			},
 			max:     100,
-			exWidth: len(" coconut "),
+			exWidth: textWidth(" coconut "),
 		},
 		{
 			in: []string{
@@ -185,9 +174,9 @@ func TestFormatText(t *testing.T) {
 				"coconut",
 			},
 			expected: []string{
-				" a... ",
-				" b... ",
-				" c... ",
+				" app… ",
+				" ban… ",
+				" coc… ",
 			},
 			max:     6,
 			exWidth: 6,
@@ -204,3 +193,11 @@ func TestFormatText(t *testing.T) {
 		}
 	}
 }
+
+// formatChoices formats 'choices' the way the completion menu does.
+func formatChoices(choices []Choice, maxWidth Column) ([]Choice, Column) {
+	c := NewCompletionManager(nil, 6)
+	c.choices = choices
+	formatted, width, _ := c.FormatChoices(maxWidth, 0)
+	return formatted, width
+}
@@ -61,38 +61,27 @@ func (d *Document) CursorDisplayCoord(termWidth Column) Coord {
 
 // CursorDisplayCoordWithPrefix same as CursorCoord but with prefix(es) taken into account.
 // It is assumed that the text starts at column 0.
+// Rows wrap at 'termWidth' like on the terminal, e.g. a wide character that doesn't fit goes on the next row.
 func (d *Document) CursorDisplayCoordWithPrefix(termWidth Column, prefix func(doc *Document, row Row) string) Coord {
+	return layoutText(d, termWidth, styledPrefix(prefix)).cursor(d.cursor)
+}
 
-	// TODO: this assumes that strings returned by prefix() does not contain '\n'
-
-	var x Column
-	var y Row
-	var cpos Index
-	var end int
-	done := false
-	for row, rtext := range d.lines() {
-		if end = Index(len(rtext)); cpos+end >= d.cursor {
-			end = d.cursor - cpos
-			done = true // this is the last line we'll process
-		}
-		var w Column
-		if prefix != nil {
-			w = Column(runewidth.StringWidth(prefix(d, Row(row))))
-		}
-		w += Column(runewidth.StringWidth(string(rtext[:end])))
-
-		x = w % termWidth
-		y += 1 + Row(w/termWidth)
-
-		advance := Index(Offset(len(rtext)) + LFsize)
-		cpos += advance
+// TranslateDisplayCoordToIndex returns the index of the character displayed at 'pos',
+// i.e. the inverse of CursorDisplayCoordWithPrefix.
+// A position left of a line's text maps to the beginning of that line, and one right of it to its end.
+// 'ok' is false if 'pos' is below the last row of the text.
+func (d *Document) TranslateDisplayCoordToIndex(termWidth Column, prefix func(doc *Document, row Row) string, pos Coord) (index Index, ok bool) {
+	return layoutText(d, termWidth, styledPrefix(prefix)).index(pos)
+}
 
-		if done {
-			break
-		}
+// styledPrefix returns a prefix function returning StyledText for one returning strings.
+func styledPrefix(prefix func(doc *Document, row Row) string) func(doc *Document, row Row) StyledText {
+	if prefix == nil {
+		return nil
+	}
+	return func(doc *Document, row Row) StyledText {
+		return plainText(prefix(doc, row))
 	}
-	// -1: we want 'index', not 'number-of-lines'
-	return Coord{x, y - 1}
 }
 
 // GetCharRelativeToCursor return character relative to cursor position (0 = at cursor), or empty string
@@ -29,11 +29,10 @@ func ExampleDocument_CursorTextColumn() {
 
 func ExampleDocument_CursorTextColumn_withJapanese() {
 	d := NewDocument(`こんにちは、芝田 将です。`, len([]rune("こ")))
+	// (`こ` is 2 terminal columns wide)
 	fmt.Println("CursorTextColumn", d.CursorTextColumn())
 	// Output:
 	// CursorTextColumn 2
-
-	// (`こ` is 2 terminal columns wide)
 }
 
 func ExampleDocument_CursorRow() {
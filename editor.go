@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/tatsujin/go-prompt/internal/debug"
+)
+
+// editorCommand returns the command line of the user's preferred editor.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editInEditor writes the buffer to a temporary file, lets the user edit it
+// in $VISUAL/$EDITOR and reads the result back into the buffer.
+// The terminal must not be in raw mode while this runs (see runSuspended).
+func (p *Prompt) editInEditor() {
+	text, err := editText(p.buf.Text(), editorCommand())
+	if err != nil {
+		debug.Log("external editor failed: " + err.Error())
+		return
+	}
+	p.buf = NewBuffer()
+	p.buf.InsertText(text, false, true)
+	p.completion.Reset()
+}
+
+// editText runs the editor 'command' on a temporary file containing 'text',
+// and returns the edited text.
+func editText(text string, command []string) (string, error) {
+	f, err := ioutil.TempFile("", "go-prompt-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(command[0], append(command[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	// editors usually add a trailing line break
+	return strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"), nil
+}
@@ -0,0 +1,35 @@
+package prompt
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestEditText(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	// the file name is passed as the last argument, i.e. $0 of the script
+	editor := []string{"sh", "-c", `test "$(cat "$0")" = "echo hello" && printf 'echo world\n' > "$0"`}
+
+	text, err := editText("echo hello", editor)
+	if err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if text != "echo world" {
+		t.Errorf("Should be %#v, but got %#v", "echo world", text)
+	}
+
+	if _, err := editText("echo hello", []string{"false"}); err == nil {
+		t.Errorf("Should fail when the editor fails")
+	}
+}
+
+func TestEditInEditorKeySequence(t *testing.T) {
+	p := newTestPrompt()
+	p.feed("\x18")
+	p.feed("\x05")
+	if !p.externalEdit {
+		t.Errorf("Should request an external editor after C-x C-e")
+	}
+}
@@ -1,9 +1,5 @@
 package prompt
 
-import (
-	"github.com/tatsujin/go-prompt/internal/debug"
-)
-
 /*
 
 ========
@@ -36,6 +32,7 @@ Editing
 * [ ] Alt + t    Swap the word before the cursor with the word on/after the cursor.
 
 * [x] Ctrl + y   Paste (yank) the last thing to be cut.
+* [x] Ctrl + x Ctrl + e  Edit the input in $VISUAL/$EDITOR.
 * [ ] Ctrl + _   Undo.
 
 * [x] Ctrl + Del Delete word after cursor
@@ -71,12 +68,7 @@ var emacsKeyBindings = map[KeyCode]KeyBindFunc{
 		}
 	},
 	// Clear the Screen, similar to the clear command
-	KeyControl | KeyL: func(*Event) {
-		// TODO: clear_screen(e.Buffer())
-		consoleWriter.EraseScreen()
-		consoleWriter.CursorGoTo(0, 0)
-		debug.AssertNoError(consoleWriter.Flush())
-	},
+	KeyControl | KeyL:     clear_screen,
 	KeyControl | KeyH:     backward_delete_char,
 	KeyControl | KeyF:     forward_char,
 	KeyControl | KeyB:     backward_char,
@@ -102,3 +94,12 @@ var emacsKeyBindings = map[KeyCode]KeyBindFunc{
 		fmt.Fprintln(os.Stderr, "M-l")
 	},*/
 }
+
+var emacsKeySequenceBindings = []SequenceBind{
+	// Toggle between the start of line and current cursor position
+	{Sequence: []KeyCode{KeyControl | KeyX, KeyControl | KeyX}, Fn: toggle_beginning_of_line},
+	// Edit the input in $VISUAL/$EDITOR
+	{Sequence: []KeyCode{KeyControl | KeyX, KeyControl | KeyE}, Fn: edit_in_editor},
+}
+
+var emacsKeyMap = keyMapFromBindings(emacsKeySequenceBindings)
@@ -16,6 +16,8 @@ type Event struct {
 	translatedKey KeyCode
 	endEdit       bool
 	eof           bool
+	externalEdit  bool
+	clearScreen   bool
 	termTitle     *string // nil meaning it's not been set
 }
 
@@ -45,9 +47,15 @@ func (e *Event) ControlSequence() ControlSequence {
 	return e.ctrlSeq
 }
 
+// CallFunction calls a key bind function by its (readline) name, e.g. "backward-kill-word".
+// The arguments are currently unused.
 func (e *Event) CallFunction(name string, args ...interface{}) {
-	// TODO
-	fmt.Fprintf(os.Stderr, "CallFunction: '%s' args: %v\n", name, args)
+	fn, ok := keyBindFuncs[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "CallFunction: unknown function '%s' args: %v\n", name, args)
+		return
+	}
+	fn(e)
 }
 
 func (e *Event) SetEOF() {
@@ -58,6 +66,16 @@ func (e *Event) SetEndEdit() {
 	e.endEdit = true
 }
 
+// SetExternalEdit requests the buffer to be edited in an external editor ($VISUAL or $EDITOR).
+func (e *Event) SetExternalEdit() {
+	e.externalEdit = true
+}
+
+// SetClearScreen requests the screen to be cleared, with the prompt redrawn at the top.
+func (e *Event) SetClearScreen() {
+	e.clearScreen = true
+}
+
 func (e *Event) SetTranslatedKey(key KeyCode) {
 	e.translatedKey = key
 }
@@ -9,36 +9,36 @@ func TestFilter(t *testing.T) {
 	var scenarioTable = []struct {
 		scenario   string
 		filter     Filter
-		list       []Suggest
+		list       []Choice
 		substr     string
 		ignoreCase bool
-		expected   []Suggest
+		expected   []Choice
 	}{
 		{
 			scenario: "Contains don't ignore case",
 			filter:   FilterContains,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fghij"},
 				{Text: "ABCDE"},
 			},
 			substr:     "cd",
 			ignoreCase: false,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 			},
 		},
 		{
 			scenario: "Contains ignore case",
 			filter:   FilterContains,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fghij"},
 				{Text: "ABCDE"},
 			},
 			substr:     "cd",
 			ignoreCase: true,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 				{Text: "ABCDE"},
 			},
@@ -46,28 +46,28 @@ func TestFilter(t *testing.T) {
 		{
 			scenario: "HasPrefix don't ignore case",
 			filter:   FilterHasPrefix,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fghij"},
 				{Text: "ABCDE"},
 			},
 			substr:     "abc",
 			ignoreCase: false,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 			},
 		},
 		{
 			scenario: "HasPrefix ignore case",
 			filter:   FilterHasPrefix,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fabcj"},
 				{Text: "ABCDE"},
 			},
 			substr:     "abc",
 			ignoreCase: true,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 				{Text: "ABCDE"},
 			},
@@ -75,28 +75,28 @@ func TestFilter(t *testing.T) {
 		{
 			scenario: "HasSuffix don't ignore case",
 			filter:   FilterHasSuffix,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fcdej"},
 				{Text: "ABCDE"},
 			},
 			substr:     "cde",
 			ignoreCase: false,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 			},
 		},
 		{
 			scenario: "HasSuffix ignore case",
 			filter:   FilterHasSuffix,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fcdej"},
 				{Text: "ABCDE"},
 			},
 			substr:     "cde",
 			ignoreCase: true,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 				{Text: "ABCDE"},
 			},
@@ -104,28 +104,28 @@ func TestFilter(t *testing.T) {
 		{
 			scenario: "Fuzzy don't ignore case",
 			filter:   FilterFuzzy,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fcdej"},
 				{Text: "ABCDE"},
 			},
 			substr:     "ae",
 			ignoreCase: false,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 			},
 		},
 		{
 			scenario: "Fuzzy ignore case",
 			filter:   FilterFuzzy,
-			list: []Suggest{
+			list: []Choice{
 				{Text: "abcde"},
 				{Text: "fcdej"},
 				{Text: "ABCDE"},
 			},
 			substr:     "ae",
 			ignoreCase: true,
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: "abcde"},
 				{Text: "ABCDE"},
 			},
@@ -0,0 +1,302 @@
+package prompt
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+var defaultStyle = Style{Fg: DefaultColor, Bg: DefaultColor}
+
+// normalized returns the style with nil colors replaced by DefaultColor, so styles can be compared.
+func (s Style) normalized() Style {
+	if s.Fg == nil {
+		s.Fg = DefaultColor
+	}
+	if s.Bg == nil {
+		s.Bg = DefaultColor
+	}
+	if s.UnderlineColor == DefaultColor {
+		s.UnderlineColor = nil
+	}
+	if !s.Underline {
+		s.UnderlineStyle, s.UnderlineColor = UnderlineSingle, nil
+	}
+	return s
+}
+
+// cell is a character on the screen; the cell to the right of a wide character has r == 0.
+type cell struct {
+	r     rune
+	style Style
+}
+
+var blankCell = cell{r: ' ', style: defaultStyle}
+
+// unknownCell is a cell whose content isn't known; it's different from every cell that's displayed.
+var unknownCell = cell{r: -1}
+
+// frame is what the renderer displays: rows of cells, starting at the prompt's home position.
+// Frames are compared with the previously displayed one, so only the changes are output (see Render.paint).
+type frame struct {
+	width  Column
+	rows   [][]cell
+	cursor Coord // where the cursor is left
+}
+
+func newFrame(width Column) *frame {
+	return &frame{width: width}
+}
+
+// row returns row 'y', adding blank rows as needed.
+func (f *frame) row(y Row) []cell {
+	for Row(len(f.rows)) <= y {
+		row := make([]cell, f.width)
+		for x := range row {
+			row[x] = blankCell
+		}
+		f.rows = append(f.rows, row)
+	}
+	return f.rows[y]
+}
+
+// write writes 'text' at 'pos', wrapping at the frame's width, and returns the position after it.
+// The position may be just past the end of a row (X == width), see wrapped.
+// SGR escape sequences in 'text' change the style, OSC 8 ones the hyperlink;
+// other escape sequences and control characters are ignored.
+func (f *frame) write(pos Coord, text string, style Style) Coord {
+	f.row(pos.Y)
+	return walkText(pos, f.width, text, style.normalized(), f.put)
+}
+
+// put writes a character of width 'w' at 'pos' (where it fits, see place).
+func (f *frame) put(pos Coord, r rune, w Column, style Style) {
+	row := f.row(pos.Y)
+	row[pos.X] = cell{r: r, style: style.normalized()}
+	if w == 2 {
+		row[pos.X+1] = cell{style: style.normalized()}
+	}
+}
+
+// place returns where a character of width 'w' is displayed when written at 'pos' (wrapping at 'width'),
+// and the position after it; a wide character that doesn't fit at the end of a row goes on the next one.
+func place(pos Coord, w, width Column) (at, next Coord) {
+	if pos.X+w > width {
+		pos = Coord{0, pos.Y + 1}
+	}
+	return pos, Coord{pos.X + w, pos.Y}
+}
+
+// walkText lays out 'text' from 'pos', wrapping at 'width', and returns the position after it.
+// 'fn' (if not nil) is called for each displayed character, with its position and style;
+// SGR escape sequences in 'text' change the style (and OSC 8 ones its hyperlink),
+// other escape sequences and control characters are skipped.
+func walkText(pos Coord, width Column, text string, style Style, fn func(at Coord, r rune, w Column, style Style)) Coord {
+	for i := 0; i < len(text); {
+		if text[i] == 0x1b {
+			n, params, final := escapeSequence(text[i:])
+			switch final {
+			case 'm':
+				link := style.link // a hyperlink isn't ended by resetting the attributes
+				style = applySGR(style, params)
+				style.link = link
+			case ']':
+				if url, ok := hyperlinkTarget(params); ok {
+					style.link = url
+				}
+			}
+			i += n
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(text[i:])
+		i += n
+
+		w := Column(runewidth.RuneWidth(r))
+		if w == 0 || w > width {
+			continue
+		}
+		var at Coord
+		at, pos = place(pos, w, width)
+		if fn != nil {
+			fn(at, r, w, style)
+		}
+	}
+	return pos
+}
+
+// textWidth returns the number of columns 'text' takes on a single row (escape sequences take none).
+func textWidth(text string) Column {
+	return walkText(Coord{}, math.MaxInt32, text, defaultStyle, nil).X
+}
+
+// truncateText is like runewidth.Truncate, except that escape sequences take no width
+// and are all kept (so e.g. a hyperlink is still ended).
+func truncateText(text string, width Column, tail string) string {
+	if textWidth(text) <= width {
+		return text
+	}
+	limit := width - textWidth(tail)
+	var b strings.Builder
+	var w Column
+	truncated := false
+	for i := 0; i < len(text); {
+		if text[i] == 0x1b {
+			n, _, _ := escapeSequence(text[i:])
+			b.WriteString(text[i : i+n])
+			i += n
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(text[i:])
+		i += n
+		if truncated {
+			continue
+		}
+		if rw := Column(runewidth.RuneWidth(r)); w+rw <= limit {
+			b.WriteRune(r)
+			w += rw
+		} else {
+			b.WriteString(tail)
+			truncated = true
+		}
+	}
+	return b.String()
+}
+
+// wrapped returns 'pos' moved to the start of the next row if it's past the end of a row,
+// i.e. where the cursor goes after writing the last column.
+func (f *frame) wrapped(pos Coord) Coord {
+	if pos.X >= f.width {
+		pos = Coord{0, pos.Y + 1}
+	}
+	f.row(pos.Y)
+	return pos
+}
+
+// escapeSequence returns the length of the escape sequence at the start of 's',
+// and for CSI sequences their parameters and final byte (for OSC sequences, their data and ']').
+func escapeSequence(s string) (n int, params string, final byte) {
+	if len(s) < 2 {
+		return len(s), "", 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1, s[2:i], s[i]
+			}
+		}
+	case ']': // OSC, terminated by BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1, s[2:i], ']'
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2, s[2:i], ']'
+			}
+		}
+	default:
+		return 2, "", 0
+	}
+	return len(s), "", 0
+}
+
+// applySGR returns 'style' changed by the parameters of an SGR ("Select Graphic Rendition") sequence.
+func applySGR(style Style, params string) Style {
+	if params == "" {
+		return defaultStyle
+	}
+	args := strings.Split(params, ";")
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "4:") { // an underline style
+			v, _ := strconv.Atoi(args[i][2:])
+			style.Underline = v != 0
+			if v > 0 {
+				style.UnderlineStyle = UnderlineStyle(v - 1)
+			}
+			continue
+		}
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			style = defaultStyle
+		case n == 1:
+			style.Bold = true
+		case n == 2:
+			style.Faint = true
+		case n == 22:
+			style.Bold, style.Faint = false, false
+		case n == 3:
+			style.Italic = true
+		case n == 23:
+			style.Italic = false
+		case n == 4:
+			style.Underline, style.UnderlineStyle = true, UnderlineSingle
+		case n == 21:
+			style.Underline, style.UnderlineStyle = true, UnderlineDouble
+		case n == 24:
+			style.Underline = false
+		case n == 5:
+			style.Blink = true
+		case n == 25:
+			style.Blink = false
+		case n == 7:
+			style.Reverse = true
+		case n == 27:
+			style.Reverse = false
+		case n == 8:
+			style.Conceal = true
+		case n == 28:
+			style.Conceal = false
+		case n == 9:
+			style.CrossedOut = true
+		case n == 29:
+			style.CrossedOut = false
+		case n == 59:
+			style.UnderlineColor = nil
+		case n >= 30 && n <= 37:
+			style.Fg = Black + AnsiColor(n-30)
+		case n >= 90 && n <= 97:
+			style.Fg = BrightBlack + AnsiColor(n-90)
+		case n == 39:
+			style.Fg = DefaultColor
+		case n >= 40 && n <= 47:
+			style.Bg = Black + AnsiColor(n-40)
+		case n >= 100 && n <= 107:
+			style.Bg = BrightBlack + AnsiColor(n-100)
+		case n == 49:
+			style.Bg = DefaultColor
+		case (n == 38 || n == 48 || n == 58) && i+4 < len(args) && args[i+1] == "2":
+			var rgb [3]uint8
+			for j := range rgb {
+				v, _ := strconv.Atoi(args[i+2+j])
+				rgb[j] = uint8(v)
+			}
+			style = style.withColor(n, NewRGB(rgb[0], rgb[1], rgb[2]))
+			i += 4
+		case (n == 38 || n == 48 || n == 58) && i+2 < len(args) && args[i+1] == "5":
+			v, _ := strconv.Atoi(args[i+2])
+			style = style.withColor(n, Ansi256Color(v))
+			i += 2
+		}
+	}
+	return style
+}
+
+// withColor returns the style with the color of an extended color SGR parameter (38, 48 or 58) set to 'c'.
+func (s Style) withColor(param int, c Color) Style {
+	switch param {
+	case 38:
+		s.Fg = c
+	case 48:
+		s.Bg = c
+	default:
+		s.UnderlineColor = c
+	}
+	return s
+}
@@ -2,9 +2,9 @@ module github.com/tatsujin/go-prompt
 
 require (
 	github.com/mattn/go-colorable v0.0.9
-	github.com/mattn/go-isatty v0.0.3 // indirect
+	github.com/mattn/go-isatty v0.0.3
 	github.com/mattn/go-runewidth v0.0.3
 	github.com/mattn/go-tty v0.0.0-20180219170247-931426f7535a
 	github.com/pkg/term v0.0.0-20180423043932-cda20d4ac917
-	golang.org/x/sys v0.0.0-20180620133508-ad87a3a340fa // indirect
+	golang.org/x/sys v0.0.0-20180620133508-ad87a3a340fa
 )
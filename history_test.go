@@ -1,38 +1,33 @@
 package prompt
 
 import (
-	"reflect"
 	"testing"
 )
 
-func TestHistoryClear(t *testing.T) {
+func TestHistoryClearModified(t *testing.T) {
 	h := NewHistory()
 	h.Add("foo")
-	h.Clear()
-	expected := &History{
-		histories: []string{"foo"},
-		tmp:       []string{"foo", ""},
-		selected:  1,
-	}
-	if !reflect.DeepEqual(expected, h) {
-		t.Errorf("Should be %#v, but got %#v", expected, h)
+	buf := NewBuffer()
+	buf.InsertText("bar", false, true)
+	h.Previous(buf)
+	h.ClearModified()
+	if len(h.modified) != 0 || h.selected != -1 {
+		t.Errorf("Should be cleared, but got modified=%#v selected=%d", h.modified, h.selected)
 	}
 }
 
 func TestHistoryAdd(t *testing.T) {
 	h := NewHistory()
 	h.Add("echo 1")
-	expected := &History{
-		histories: []string{"echo 1"},
-		tmp:       []string{"echo 1", ""},
-		selected:  1,
+	if len(h.history) != 1 || h.history[0].text != "echo 1" {
+		t.Errorf("Should be %#v, but got %#v", "echo 1", h.history)
 	}
-	if !reflect.DeepEqual(h, expected) {
-		t.Errorf("Should be %v, but got %v", expected, h)
+	if h.selected != -1 {
+		t.Errorf("Should be %v, but got %v", -1, h.selected)
 	}
 }
 
-func TestHistoryOlder(t *testing.T) {
+func TestHistoryPrevious(t *testing.T) {
 	h := NewHistory()
 	h.Add("echo 1")
 
@@ -40,23 +35,21 @@ func TestHistoryOlder(t *testing.T) {
 	buf := NewBuffer()
 	buf.InsertText("echo 2", false, true)
 
-	// [1 time] Call Older function
-	buf1, changed := h.Older(buf)
-	if !changed {
-		t.Error("Should be changed history but not changed.")
-	}
+	// [1 time] Call Previous function
+	buf1 := h.Previous(buf)
 	if buf1.Text() != "echo 1" {
 		t.Errorf("Should be %#v, but got %#v", "echo 1", buf1.Text())
 	}
 
-	// [2 times] Call Older function
-	buf = NewBuffer()
-	buf.InsertText("echo 1", false, true)
-	buf2, changed := h.Older(buf)
-	if changed {
+	// [2 times] Call Previous function; already at the oldest entry
+	buf2 := h.Previous(buf1)
+	if buf2 != buf1 {
 		t.Error("Should be not changed history but changed.")
 	}
-	if !reflect.DeepEqual("echo 1", buf2.Text()) {
-		t.Errorf("Should be %#v, but got %#v", "echo 1", buf2.Text())
+
+	// back to the (modified) text being edited
+	buf3 := h.Next(buf2)
+	if buf3.Text() != "echo 2" {
+		t.Errorf("Should be %#v, but got %#v", "echo 2", buf3.Text())
 	}
 }
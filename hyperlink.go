@@ -0,0 +1,25 @@
+package prompt
+
+import "strings"
+
+// hyperlinkStart starts OSC 8 sequences.
+const hyperlinkStart = "\x1b]8;"
+
+// Hyperlink returns 'text' as an OSC 8 hyperlink to 'url', e.g. for a Choice.Description or Prompt.OutputAsync.
+// Terminals without hyperlinks just show the text; the escape sequences take no width in the completion menu.
+func Hyperlink(url, text string) string {
+	var w VT100Writer
+	w.SetHyperlink(url)
+	w.WriteRawStr(text)
+	w.SetHyperlink("")
+	return string(w.buffer)
+}
+
+// hyperlinkTarget returns the URL of an OSC 8 sequence from its data ("8;params;url"), "" for the end of a link.
+func hyperlinkTarget(data string) (url string, ok bool) {
+	parts := strings.SplitN(data, ";", 3)
+	if len(parts) != 3 || parts[0] != "8" {
+		return "", false
+	}
+	return parts[2], true
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatTextsHyperlink(t *testing.T) {
+	scenarioTable := []struct {
+		in       []string
+		expected []string
+		max      Column
+		exWidth  Column
+	}{
+		{
+			in: []string{
+				Hyperlink("https://example.com/apple", "apple"),
+				"banana",
+			},
+			expected: []string{
+				" \x1b]8;;https://example.com/apple\x1b\\apple\x1b]8;;\x1b\\  ",
+				" banana ",
+			},
+			max:     100,
+			exWidth: Column(len(" banana ")),
+		},
+		{
+			in: []string{
+				Hyperlink("https://example.com/coconut", "coconut"),
+			},
+			expected: []string{
+				" \x1b]8;;https://example.com/coconut\x1b\\coc…\x1b]8;;\x1b\\ ",
+			},
+			max:     6,
+			exWidth: 6,
+		},
+	}
+
+	for i, s := range scenarioTable {
+		actual, width := formatTexts(s.in, s.max, " ", " ")
+		if width != s.exWidth {
+			t.Errorf("[scenario %d] Want %d but got %d\n", i, s.exWidth, width)
+		}
+		if !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("[scenario %d] Want %#v, but got %#v\n", i, s.expected, actual)
+		}
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	link := Hyperlink("https://example.com", "docs")
+	if expected := "\x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\"; link != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, link)
+	}
+	if w := textWidth(link); w != 4 {
+		t.Errorf("Should be %v, but got %v", 4, w)
+	}
+}
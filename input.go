@@ -1,5 +1,10 @@
 package prompt
 
+import (
+	"bytes"
+	"errors"
+)
+
 // WinSize represents the width and height of terminal.
 type WinSize struct {
 	Row uint16
@@ -18,19 +23,106 @@ type ConsoleParser interface {
 	Read() ([]byte, error)
 }
 
+// ErrReadCanceled is returned by the Read of a CancelableParser when it was canceled.
+var ErrReadCanceled = errors.New("read canceled")
+
+// CancelableParser is a ConsoleParser whose Read blocks until there is input.
+// Parsers that don't implement it are expected to return from Read when there's no input,
+// in which case they're polled.
+type CancelableParser interface {
+	ConsoleParser
+	// Cancel makes a blocked (or the next) Read return ErrReadCanceled.
+	Cancel() error
+}
+
+// WinSizeNotifier is a ConsoleParser that reports size changes itself, rather than with SIGWINCH
+// (e.g. a ReplayParser).
+type WinSizeNotifier interface {
+	ConsoleParser
+	// WinSizeChanged returns a channel that receives when the size returned by GetWinSize has changed.
+	// A change is signaled before the input read after it is returned.
+	WinSizeChanged() <-chan struct{}
+}
+
 // FindKey returns Key correspond to input byte codes, or Undefined if no key is defined.
 func FindKey(cs ControlSequence) KeyCode {
 	if key, ok := KeySequences[cs]; ok {
 		return key
 	}
+	if key, ok := decodeKey(cs); ok {
+		return key
+	}
+	if len(cs) == 2 && cs[0] == 0x1b {
+		// ESC prefix, i.e. Meta (e.g. "\x1b." is M-.)
+		if key, ok := KeySequences[cs[1:]]; ok {
+			return KeyAlt | key
+		}
+		if key := keyFromRune(rune(cs[1])); key != Undefined {
+			return KeyAlt | key
+		}
+	}
+	if _, ok := parseMouseEvent(cs); ok {
+		return Vt100MouseEvent
+	}
 	return Undefined
 }
 
+// splitSequences splits the bytes of a single read into separate control sequences,
+// e.g. when a mouse press and release, or a key and a terminal report, arrive together.
+// Runs of plain text are kept together (so a paste is still inserted in one go).
+func splitSequences(b []byte) []ControlSequence {
+	if _, ok := KeySequences[ControlSequence(b)]; ok {
+		return []ControlSequence{ControlSequence(b)}
+	}
+
+	var seqs []ControlSequence
+	for len(b) > 0 {
+		n := 1
+		if b[0] != 0x1b {
+			for n < len(b) && b[n] != 0x1b {
+				n++
+			}
+		} else if len(b) >= 2 {
+			switch b[1] {
+			case '[': // CSI: parameter & intermediate bytes, then a final byte
+				n = 2
+				for n < len(b) && b[n] >= 0x20 && b[n] <= 0x3f {
+					n++
+				}
+				if n < len(b) {
+					n++
+				}
+			case 'P': // DCS (e.g. a version report), up to ST; without it, it's Alt+Shift+P
+				if i := bytes.Index(b, []byte("\x1b\\")); i > 0 {
+					n = i + 2
+				} else {
+					n = 2
+				}
+			case 'O': // SS3
+				n = 3
+				if n > len(b) {
+					n = len(b)
+				}
+			default:
+				n = 2
+			}
+		}
+		seqs = append(seqs, ControlSequence(b[:n]))
+		b = b[n:]
+	}
+	return seqs
+}
+
 // HasControlModifier returns whether the given key code has the Control modifier.
 func HasControlModifier(k KeyCode) bool {
 	return k&KeyControl > 0
 }
 
+// HasShiftModifier returns whether the given key code has the Shift modifier.
+func HasShiftModifier(k KeyCode) bool {
+	return k&KeyShift > 0
+}
+
 // HasAltModifier returns whether the given key code has the Alt modifier.
 func HasAltModifier(k KeyCode) bool {
 	return k&KeyAlt > 0
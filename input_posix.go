@@ -3,6 +3,8 @@
 package prompt
 
 import (
+	"fmt"
+	"io"
 	"syscall"
 	"unsafe"
 
@@ -15,13 +17,19 @@ const maxReadBytes = 1024
 type PosixParser struct {
 	fd          int
 	origTermios syscall.Termios
+	cancelPipe  [2]int // Cancel writes to it to wake up a blocked Read
+	openErr     error  // why the terminal couldn't be opened, returned by Setup
 }
 
 // Setup should be called before starting input
 func (t *PosixParser) Setup() error {
-	// Set NonBlocking mode because if syscall.Read block this goroutine, it cannot receive data from stopCh.
-	if err := syscall.SetNonblock(t.fd, true); err != nil {
-		return err
+	if t.openErr != nil {
+		return t.openErr
+	}
+	if t.cancelPipe[0] < 0 {
+		if err := t.openCancelPipe(); err != nil {
+			return err
+		}
 	}
 	if err := term.SetRaw(t.fd); err != nil {
 		return err
@@ -31,25 +39,56 @@ func (t *PosixParser) Setup() error {
 
 // TearDown should be called after stopping input
 func (t *PosixParser) TearDown() error {
-	if err := syscall.SetNonblock(t.fd, false); err != nil {
-		return err
-	}
+	t.closeCancelPipe()
 	if err := term.Restore(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Read returns byte array.
+// Read returns byte array. It blocks until there is input, or Cancel is called.
+// It returns io.EOF when the input has ended (e.g. the terminal has been hung up).
 func (t *PosixParser) Read() ([]byte, error) {
+	ready, err := waitForInput(t.fd, t.cancelPipe[0])
+	if err != nil {
+		return []byte{}, err
+	}
+	if !ready {
+		t.drainCancelPipe()
+		return []byte{}, ErrReadCanceled
+	}
+
 	buf := make([]byte, maxReadBytes)
 	n, err := syscall.Read(t.fd, buf)
-	if err != nil {
+	switch {
+	case err == syscall.EINTR || err == syscall.EAGAIN:
+		return []byte{}, nil
+	case err != nil:
 		return []byte{}, err
+	case n == 0:
+		return []byte{}, io.EOF
 	}
 	return buf[:n], nil
 }
 
+// Cancel makes a blocked (or the next) Read return ErrReadCanceled.
+func (t *PosixParser) Cancel() error {
+	_, err := syscall.Write(t.cancelPipe[1], []byte{0})
+	if err == syscall.EAGAIN { // the pipe is full, i.e. already canceled
+		return nil
+	}
+	return err
+}
+
+func (t *PosixParser) drainCancelPipe() {
+	buf := make([]byte, 64)
+	for {
+		if n, err := syscall.Read(t.cancelPipe[0], buf); n <= 0 || err != nil {
+			return
+		}
+	}
+}
+
 // winsize is winsize struct got from the ioctl(2) system call.
 type ioctlWinsize struct {
 	Row uint16
@@ -76,16 +115,56 @@ func (t *PosixParser) GetWinSize() *WinSize {
 	}
 }
 
-var _ ConsoleParser = &PosixParser{}
+var _ CancelableParser = &PosixParser{}
 
 // NewStandardInputParser returns ConsoleParser object to read from stdin.
+// If the terminal can't be opened, Setup returns the error (wrapping ErrNoTerminal).
 func NewStandardInputParser() *PosixParser {
 	in, err := syscall.Open("/dev/tty", syscall.O_RDONLY, 0)
 	if err != nil {
-		panic(err)
+		return &PosixParser{fd: -1, cancelPipe: [2]int{-1, -1}, openErr: fmt.Errorf("%w: /dev/tty: %v", ErrNoTerminal, err)}
+	}
+
+	t, err := newPosixParser(in)
+	if err != nil {
+		syscall.Close(in)
+		return &PosixParser{fd: -1, cancelPipe: [2]int{-1, -1}, openErr: err}
+	}
+	return t
+}
+
+// newPosixParser returns a PosixParser reading from 'fd'.
+func newPosixParser(fd int) (*PosixParser, error) {
+	t := &PosixParser{
+		fd: fd,
+	}
+	if err := t.openCancelPipe(); err != nil {
+		return nil, err
 	}
+	return t, nil
+}
+
+// openCancelPipe opens the pipe Cancel writes to; TearDown closes it, and Setup opens it again.
+func (t *PosixParser) openCancelPipe() error {
+	if err := syscall.Pipe(t.cancelPipe[:]); err != nil {
+		t.cancelPipe = [2]int{-1, -1}
+		return err
+	}
+	for _, fd := range t.cancelPipe {
+		syscall.CloseOnExec(fd)
+		if err := syscall.SetNonblock(fd, true); err != nil {
+			t.closeCancelPipe()
+			return err
+		}
+	}
+	return nil
+}
 
-	return &PosixParser{
-		fd: in,
+func (t *PosixParser) closeCancelPipe() {
+	for i, fd := range t.cancelPipe {
+		if fd >= 0 {
+			syscall.Close(fd)
+			t.cancelPipe[i] = -1
+		}
 	}
 }
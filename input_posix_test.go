@@ -0,0 +1,73 @@
+// +build !windows
+
+package prompt
+
+import (
+	"io"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPosixParserCancel(t *testing.T) {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+
+	in, err := newPosixParser(fds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		b   []byte
+		err error
+	}
+	read := func() chan result {
+		ch := make(chan result, 1)
+		go func() {
+			b, err := in.Read()
+			ch <- result{b, err}
+		}()
+		return ch
+	}
+	wait := func(ch chan result) result {
+		select {
+		case r := <-ch:
+			return r
+		case <-time.After(time.Second):
+			t.Fatal("Read should have returned")
+		}
+		return result{}
+	}
+
+	// a blocked read returns when canceled
+	ch := read()
+	if err := in.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if r := wait(ch); r.err != ErrReadCanceled {
+		t.Errorf("Should be %v, but got %v", ErrReadCanceled, r.err)
+	}
+
+	// ...and when there's input (the cancellation was consumed)
+	ch = read()
+	syscall.Write(fds[1], []byte("abc"))
+	if r := wait(ch); r.err != nil || string(r.b) != "abc" {
+		t.Errorf("Should be %#v, but got %#v (%v)", "abc", string(r.b), r.err)
+	}
+
+	// ...and io.EOF when the input has ended
+	syscall.Close(fds[1])
+	if r := wait(read()); r.err != io.EOF {
+		t.Errorf("Should be %v, but got %v", io.EOF, r.err)
+	}
+
+	in.closeCancelPipe()
+	if in.cancelPipe != [2]int{-1, -1} {
+		t.Errorf("Should be %v, but got %v", [2]int{-1, -1}, in.cancelPipe)
+	}
+}
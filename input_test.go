@@ -13,7 +13,7 @@ func TestPosixParserGetKey(t *testing.T) {
 		{
 			name:     "escape",
 			input:    "\x1b",
-			expected: Escape,
+			expected: KeyEscape,
 		},
 		{
 			name:     "undefined",
@@ -26,7 +26,7 @@ func TestPosixParserGetKey(t *testing.T) {
 		t.Run(s.name, func(t *testing.T) {
 			key := FindKey(s.input)
 			if key != s.expected {
-				t.Errorf("Expected %d, but got %d", key, s.expected)
+				t.Errorf("Expected %d, but got %d", s.expected, key)
 			}
 		})
 	}
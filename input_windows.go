@@ -3,7 +3,7 @@
 package prompt
 
 import (
-	"errors"
+	"fmt"
 	"syscall"
 	"unicode/utf8"
 	"unsafe"
@@ -15,18 +15,28 @@ const maxReadBytes = 1024
 
 var kernel32 = syscall.NewLazyDLL("kernel32.dll")
 
-var procGetNumberOfConsoleInputEvents = kernel32.NewProc("GetNumberOfConsoleInputEvents")
+var (
+	procGetNumberOfConsoleInputEvents = kernel32.NewProc("GetNumberOfConsoleInputEvents")
+	procCreateEventW                  = kernel32.NewProc("CreateEventW")
+	procSetEvent                      = kernel32.NewProc("SetEvent")
+	procWaitForMultipleObjects        = kernel32.NewProc("WaitForMultipleObjects")
+)
 
 // WindowsParser is a ConsoleParser implementation for Win32 console.
 type WindowsParser struct {
-	tty *tty.TTY
+	tty    *tty.TTY
+	cancel syscall.Handle // an event which Cancel sets to wake up a blocked Read
+	err    error          // returned by Setup, if the cancel event couldn't be created
 }
 
 // Setup should be called before starting input
 func (p *WindowsParser) Setup() error {
+	if p.err != nil {
+		return p.err
+	}
 	t, err := tty.Open()
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrNoTerminal, err)
 	}
 	p.tty = t
 	return nil
@@ -37,15 +47,28 @@ func (p *WindowsParser) TearDown() error {
 	return p.tty.Close()
 }
 
-// Read returns byte array.
+// Read returns byte array. It blocks until there is input, or Cancel is called.
 func (p *WindowsParser) Read() ([]byte, error) {
-	var ev uint32
-	r0, _, err := procGetNumberOfConsoleInputEvents.Call(p.tty.Input().Fd(), uintptr(unsafe.Pointer(&ev)))
-	if r0 == 0 {
-		return nil, err
-	}
-	if ev == 0 {
-		return nil, errors.New("EAGAIN")
+	in := p.tty.Input().Fd()
+	for {
+		handles := [2]syscall.Handle{p.cancel, syscall.Handle(in)}
+		r0, _, err := procWaitForMultipleObjects.Call(2, uintptr(unsafe.Pointer(&handles[0])), 0, syscall.INFINITE)
+		switch r0 {
+		case syscall.WAIT_OBJECT_0: // the (auto-reset) cancel event
+			return nil, ErrReadCanceled
+		case syscall.WAIT_OBJECT_0 + 1:
+		default:
+			return nil, err
+		}
+
+		var ev uint32
+		r0, _, err = procGetNumberOfConsoleInputEvents.Call(in, uintptr(unsafe.Pointer(&ev)))
+		if r0 == 0 {
+			return nil, err
+		}
+		if ev > 0 {
+			break
+		}
 	}
 
 	r, err := p.tty.ReadRune()
@@ -65,6 +88,14 @@ func (p *WindowsParser) Read() ([]byte, error) {
 	return buf[:n], nil
 }
 
+// Cancel makes a blocked (or the next) Read return ErrReadCanceled.
+func (p *WindowsParser) Cancel() error {
+	if r0, _, err := procSetEvent.Call(uintptr(p.cancel)); r0 == 0 {
+		return err
+	}
+	return nil
+}
+
 // GetWinSize returns WinSize object to represent width and height of terminal.
 func (p *WindowsParser) GetWinSize() *WinSize {
 	w, h, err := p.tty.Size()
@@ -77,7 +108,16 @@ func (p *WindowsParser) GetWinSize() *WinSize {
 	}
 }
 
+var _ CancelableParser = &WindowsParser{}
+
 // NewStandardInputParser returns ConsoleParser object to read from stdin.
 func NewStandardInputParser() *WindowsParser {
-	return &WindowsParser{}
+	// an auto-reset event, initially not set
+	h, _, err := procCreateEventW.Call(0, 0, 0, 0)
+	if h == 0 {
+		return &WindowsParser{err: err}
+	}
+	return &WindowsParser{
+		cancel: syscall.Handle(h),
+	}
 }
@@ -0,0 +1,512 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tatsujin/go-prompt/internal/debug"
+)
+
+/*
+
+An inputrc file configures key bindings and settings, using (a subset of)
+the format of readline's ~/.inputrc:
+
+	# comments start with '#'
+	set editing-mode emacs
+	set keyseq-timeout 500
+
+	$if mode=emacs
+	"\C-x\C-e": edit-in-editor
+	Control-a: beginning-of-line
+	Meta-Rubout: backward-kill-word
+	"\C-xd": "docker "
+	$endif
+
+	$include ~/.inputrc.local
+
+A key binding is either a quoted key sequence or a key name, followed by ':' and
+the name of a function (see keyBindFuncs) or a quoted text to insert (a macro).
+
+Key sequences may contain the escapes \C- (control), \M- (meta), \e (escape),
+\\, \", \', \a, \b, \d, \f, \n, \r, \t, \v, \nnn (octal) and \xHH (hex).
+Key names are e.g. "Control-x", "C-x", "Meta-f", "M-Rubout" or "Control-Meta-h";
+the named keys are DEL, ESC, ESCAPE, LFD, NEWLINE, RET, RETURN, RUBOUT, SPACE, SPC and TAB.
+
+Supported variables are editing-mode (emacs) and keyseq-timeout (in milliseconds,
+zero or less waits forever); others are ignored.
+
+Conditionals are "$if mode=emacs", "$if term=<name>" (matched against $TERM,
+both in full and up to the first '-'), "$else" and "$endif". Any other test is false.
+
+Bindings made in an inputrc file replace the built-in bindings of the same keys.
+
+Like readline, lines that can't be applied (e.g. an unknown function, "set editing-mode vi",
+an unknown directive or a recursive $include) are skipped; they're listed by Prompt.InputrcWarnings.
+Only failing to read the file is an error.
+
+*/
+
+// inputrcParser applies the lines of an inputrc file to a Prompt.
+type inputrcParser struct {
+	p         *Prompt
+	conds     []inputrcCond
+	including []string // the (absolute) paths of the files being loaded, to detect recursive $includes
+}
+
+// inputrcCond is an open $if block.
+type inputrcCond struct {
+	outerActive bool // whether the lines surrounding the block are applied
+	test        bool
+	inElse      bool
+}
+
+// active returns whether lines are currently applied (i.e. not skipped by a conditional).
+func (ip *inputrcParser) active() bool {
+	if len(ip.conds) == 0 {
+		return true
+	}
+	c := ip.conds[len(ip.conds)-1]
+	return c.outerActive && c.test != c.inElse
+}
+
+// load parses the inputrc file 'r'; 'name' is used in warnings and error messages.
+// Lines that can't be applied are skipped with a warning; only read errors are returned.
+func (ip *inputrcParser) load(r io.Reader, name string) error {
+	depth := len(ip.conds)
+	lineNo := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		if err := ip.parseLine(strings.TrimSpace(scanner.Text())); err != nil {
+			if _, ok := err.(inputrcReadError); ok {
+				return err
+			}
+			ip.warn(fmt.Sprintf("%s:%d: %v", name, lineNo, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return inputrcReadError{fmt.Errorf("%s: %w", name, err)}
+	}
+	if len(ip.conds) > depth {
+		ip.warn(fmt.Sprintf("%s:%d: missing $endif", name, lineNo))
+		ip.conds = ip.conds[:depth]
+	}
+	return nil
+}
+
+// loadFile parses the inputrc file at 'path'.
+func (ip *inputrcParser) loadFile(path string) error {
+	abs, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return err
+	}
+	for _, inc := range ip.including {
+		if inc == abs {
+			return fmt.Errorf("recursive $include of '%s'", path)
+		}
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ip.including = append(ip.including, abs)
+	defer func() { ip.including = ip.including[:len(ip.including)-1] }()
+	return ip.load(f, path)
+}
+
+// inputrcReadError is an error reading an inputrc file (or one it includes),
+// which ends loading it, unlike the lines that are skipped.
+type inputrcReadError struct {
+	error
+}
+
+// warn records a line that was skipped.
+func (ip *inputrcParser) warn(msg string) {
+	debug.Log("inputrc: " + msg)
+	ip.p.inputrcWarnings = append(ip.p.inputrcWarnings, msg)
+}
+
+func (ip *inputrcParser) parseLine(line string) error {
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+	if line[0] == '$' {
+		return ip.parseDirective(line)
+	}
+	if !ip.active() {
+		return nil
+	}
+	if strings.HasPrefix(line, "set") && len(line) > 3 && (line[3] == ' ' || line[3] == '\t') {
+		return ip.parseSet(strings.Fields(line[3:]))
+	}
+	return ip.parseBinding(line)
+}
+
+func (ip *inputrcParser) parseDirective(line string) error {
+	directive, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		directive, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+
+	switch directive {
+	case "$if":
+		ip.conds = append(ip.conds, inputrcCond{outerActive: ip.active(), test: ip.test(arg)})
+	case "$else":
+		if len(ip.conds) == 0 {
+			return errors.New("$else without $if")
+		}
+		ip.conds[len(ip.conds)-1].inElse = true
+	case "$endif":
+		if len(ip.conds) == 0 {
+			return errors.New("$endif without $if")
+		}
+		ip.conds = ip.conds[:len(ip.conds)-1]
+	case "$include":
+		if !ip.active() {
+			return nil
+		}
+		if err := ip.loadFile(arg); err != nil {
+			if _, ok := err.(inputrcReadError); ok {
+				return err
+			}
+			// a missing or recursively included file is skipped
+			return fmt.Errorf("$include: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown directive '%s'", directive)
+	}
+	return nil
+}
+
+// test evaluates the test of an $if directive.
+func (ip *inputrcParser) test(arg string) bool {
+	if i := strings.IndexByte(arg, '='); i >= 0 {
+		name, value := strings.TrimSpace(arg[:i]), strings.TrimSpace(arg[i+1:])
+		switch name {
+		case "mode":
+			return value == string(ip.p.editMode)
+		case "term":
+			term := os.Getenv("TERM")
+			return term == value || strings.SplitN(term, "-", 2)[0] == value
+		}
+	}
+	return false
+}
+
+func (ip *inputrcParser) parseSet(fields []string) error {
+	if len(fields) < 2 {
+		return errors.New("set: missing value")
+	}
+	name, value := fields[0], fields[1]
+
+	switch strings.ToLower(name) {
+	case "editing-mode":
+		if value != string(EmacsMode) {
+			return fmt.Errorf("unsupported editing-mode '%s'", value)
+		}
+		ip.p.editMode = EmacsMode
+	case "keyseq-timeout":
+		ms, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("keyseq-timeout: %v", err)
+		}
+		if ms < 0 {
+			ms = 0
+		}
+		ip.p.keySequenceTimeout = time.Duration(ms) * time.Millisecond
+	}
+	return nil
+}
+
+func (ip *inputrcParser) parseBinding(line string) error {
+	var seq []KeyCode
+	var rest string
+	var err error
+
+	if line[0] == '"' {
+		end := closingQuote(line)
+		if end < 0 {
+			return errors.New("missing closing quote")
+		}
+		seq, err = parseKeySequence(line[1:end])
+		rest = strings.TrimSpace(line[end+1:])
+		if !strings.HasPrefix(rest, ":") {
+			return errors.New("missing ':'")
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return errors.New("missing ':'")
+		}
+		seq, err = parseKeyName(strings.TrimSpace(line[:i]))
+		rest = line[i+1:]
+	}
+	if err != nil {
+		return err
+	}
+
+	fn, err := parseBindingValue(strings.TrimSpace(rest))
+	if err != nil {
+		return err
+	}
+	ip.p.inputrcBindings.Bind(seq, fn)
+	return nil
+}
+
+// parseBindingValue returns the function for a function name or a quoted macro text.
+func parseBindingValue(value string) (KeyBindFunc, error) {
+	if value == "" {
+		return nil, errors.New("missing function name")
+	}
+	if value[0] == '"' || value[0] == '\'' {
+		end := closingQuote(value)
+		if end < 0 {
+			return nil, errors.New("missing closing quote")
+		}
+		text, err := unescapeKeySequence(value[1:end])
+		if err != nil {
+			return nil, err
+		}
+		return func(e *Event) {
+			e.Buffer().InsertText(text, false, true)
+		}, nil
+	}
+
+	name := strings.Fields(value)[0]
+	fn, ok := keyBindFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+	return fn, nil
+}
+
+// closingQuote returns the index of the quote that closes the one starting 's', or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case s[0]:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseKeySequence parses the (unquoted) key sequence of a binding, e.g. `\C-x\C-e`.
+func parseKeySequence(s string) ([]KeyCode, error) {
+	text, err := unescapeKeySequence(s)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, errors.New("empty key sequence")
+	}
+	return keysFromText(text)
+}
+
+// unescapeKeySequence resolves the escapes of a key sequence (or macro) to the bytes they stand for.
+func unescapeKeySequence(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'C', 'M':
+			if i+2 >= len(s) || s[i+1] != '-' {
+				return "", fmt.Errorf("incomplete \\%c- escape", c)
+			}
+			// the key may itself be an escape, e.g. \M-\C-x
+			rest, err := unescapeKeySequence(s[i+2:])
+			if err != nil {
+				return "", err
+			}
+			if rest == "" {
+				return "", fmt.Errorf("incomplete \\%c- escape", c)
+			}
+			if c == 'C' && rest[0] == 0x1b && len(rest) > 1 {
+				// \C-\M-x is the same as \M-\C-x
+				rest = "\x1b" + string(controlByte(rest[1])) + rest[2:]
+			} else if c == 'C' {
+				rest = string(controlByte(rest[0])) + rest[1:]
+			} else {
+				rest = "\x1b" + rest
+			}
+			b.WriteString(rest)
+			return b.String(), nil
+		case 'e':
+			b.WriteByte(0x1b)
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'd':
+			b.WriteByte(0x7f)
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case 'x':
+			n := 0
+			for n < 2 && i+1+n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[i+1+n]) >= 0 {
+				n++
+			}
+			if n == 0 {
+				return "", errors.New("invalid \\x escape")
+			}
+			v, _ := strconv.ParseUint(s[i+1:i+1+n], 16, 8)
+			b.WriteByte(byte(v))
+			i += n
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n := 1
+			for n < 3 && i+n < len(s) && s[i+n] >= '0' && s[i+n] <= '7' {
+				n++
+			}
+			v, _ := strconv.ParseUint(s[i:i+n], 8, 8)
+			b.WriteByte(byte(v))
+			i += n - 1
+		default: // \\, \", \' and anything else is the character itself
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// controlByte returns the byte Control+c sends.
+func controlByte(c byte) byte {
+	if c == '?' {
+		return 0x7f
+	}
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	return c & 0x1f
+}
+
+// inputrcKeyNames maps (upper case) key names to the bytes the key sends.
+var inputrcKeyNames = map[string]string{
+	"DEL":     "\x7f",
+	"ESC":     "\x1b",
+	"ESCAPE":  "\x1b",
+	"LFD":     "\n",
+	"NEWLINE": "\n",
+	"RET":     "\r",
+	"RETURN":  "\r",
+	"RUBOUT":  "\x7f",
+	"SPACE":   " ",
+	"SPC":     " ",
+	"TAB":     "\t",
+}
+
+// parseKeyName parses a key name binding, e.g. "Control-Meta-x" or "M-Rubout".
+func parseKeyName(name string) ([]KeyCode, error) {
+	var control, meta bool
+	for {
+		i := strings.IndexByte(name, '-')
+		if i <= 0 || i == len(name)-1 {
+			break
+		}
+		switch strings.ToLower(name[:i]) {
+		case "control", "ctrl", "c":
+			control = true
+		case "meta", "m":
+			meta = true
+		default:
+			return nil, fmt.Errorf("unknown modifier '%s'", name[:i])
+		}
+		name = name[i+1:]
+	}
+
+	text, ok := inputrcKeyNames[strings.ToUpper(name)]
+	if !ok {
+		if len([]rune(name)) != 1 {
+			return nil, fmt.Errorf("unknown key name '%s'", name)
+		}
+		text = name
+	}
+	if control {
+		text = string(controlByte(text[0])) + text[1:]
+	}
+	if meta {
+		text = "\x1b" + text
+	}
+	return keysFromText(text)
+}
+
+// keysFromText returns the keys that send 'text'; they're decoded the way Prompt.feed does,
+// so a sequence that's accepted can be typed.
+func keysFromText(text string) ([]KeyCode, error) {
+	var keys []KeyCode
+	for _, cs := range splitSequences([]byte(text)) {
+		if cs[0] != 0x1b {
+			// plain text; one key per character
+			for _, r := range string(cs) {
+				k, err := keyFromText(string(r))
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, k)
+			}
+			continue
+		}
+		k := FindKey(cs)
+		if k == Undefined || k == Vt100MouseEvent {
+			return nil, fmt.Errorf("unknown key sequence %q", string(cs))
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// keyFromText returns the key sending the single character 'text'.
+func keyFromText(text string) (KeyCode, error) {
+	if k, ok := KeySequences[ControlSequence(text)]; ok {
+		return k, nil
+	}
+	r := []rune(text)
+	if len(r) == 1 {
+		if k := keyFromRune(r[0]); k != Undefined {
+			return k, nil
+		}
+	}
+	return Undefined, fmt.Errorf("unknown key %q", text)
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// InputrcWarnings returns the lines of the inputrc files (see OptionInputrc) that were skipped
+// because they couldn't be applied, e.g. "~/.inputrc:3: unknown function 'vi-movement-mode'".
+func (p *Prompt) InputrcWarnings() []string {
+	return p.inputrcWarnings
+}
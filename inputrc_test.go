@@ -0,0 +1,219 @@
+package prompt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeySequence(t *testing.T) {
+	scenarioTable := []struct {
+		seq      string
+		expected []KeyCode
+	}{
+		{seq: `\C-x\C-e`, expected: []KeyCode{ControlX, ControlE}},
+		{seq: `\C-xe`, expected: []KeyCode{ControlX, KeyE}},
+		{seq: `\M-f`, expected: []KeyCode{KeyAlt | KeyF}},
+		{seq: `\ef`, expected: []KeyCode{KeyAlt | KeyF}},
+		{seq: `\M-\C-x`, expected: []KeyCode{KeyAlt | ControlX}},
+		{seq: `\C-\M-x`, expected: []KeyCode{KeyAlt | ControlX}},
+		{seq: `\M-.`, expected: []KeyCode{KeyAlt | KeyPoint}},
+		{seq: `\e[A`, expected: []KeyCode{KeyUp}},
+		{seq: `\e[1;5C`, expected: []KeyCode{KeyControl | KeyRight}},
+		{seq: `\x18\005`, expected: []KeyCode{ControlX, ControlE}},
+		{seq: `\t`, expected: []KeyCode{KeyTab}},
+	}
+
+	for _, s := range scenarioTable {
+		actual, err := parseKeySequence(s.seq)
+		if err != nil {
+			t.Errorf("%s: Should be nil, but got %v", s.seq, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%s: Should be %s, but got %s", s.seq, keySequenceName(s.expected), keySequenceName(actual))
+		}
+	}
+}
+
+func TestParseKeyName(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		expected []KeyCode
+	}{
+		{name: "Control-a", expected: []KeyCode{ControlA}},
+		{name: "C-a", expected: []KeyCode{ControlA}},
+		{name: "Meta-f", expected: []KeyCode{KeyAlt | KeyF}},
+		{name: "M-Rubout", expected: []KeyCode{KeyAlt | KeyControl | KeyBackspace}},
+		{name: "Control-Meta-x", expected: []KeyCode{KeyAlt | ControlX}},
+		{name: "TAB", expected: []KeyCode{KeyTab}},
+		{name: "Space", expected: []KeyCode{KeySpace}},
+	}
+
+	for _, s := range scenarioTable {
+		actual, err := parseKeyName(s.name)
+		if err != nil {
+			t.Errorf("%s: Should be nil, but got %v", s.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%s: Should be %s, but got %s", s.name, keySequenceName(s.expected), keySequenceName(actual))
+		}
+	}
+}
+
+func TestOptionInputrc(t *testing.T) {
+	rc := `
+# test
+set keyseq-timeout 500
+set bell-style none
+
+$if mode=emacs
+Control-a: end-of-line
+"\C-xd": "docker "
+$else
+Control-a: backward-char
+$endif
+
+$if mode=vi
+"\C-xv": "vi"
+$endif
+`
+	p := newTestPrompt()
+	if err := OptionInputrc(strings.NewReader(rc))(p); err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if p.keySequenceTimeout != 500*time.Millisecond {
+		t.Errorf("Should be %v, but got %v", 500*time.Millisecond, p.keySequenceTimeout)
+	}
+
+	p.buf.InsertText("hello", false, false)
+	p.feed("\x01")
+	if ci := p.buf.Document().CursorIndex(); ci != 5 {
+		t.Errorf("Should be %v, but got %v", 5, ci)
+	}
+
+	p.feed("\x18")
+	p.feed("d")
+	if text := p.buf.Text(); text != "hellodocker " {
+		t.Errorf("Should be %#v, but got %#v", "hellodocker ", text)
+	}
+
+	if fn, _ := p.inputrcBindings.Lookup([]KeyCode{ControlX, KeyV}); fn != nil {
+		t.Errorf("Should not bind keys in an inactive $if block")
+	}
+}
+
+func TestOptionInputrcMeta(t *testing.T) {
+	rc := `
+Meta-Rubout: backward-kill-word
+"\M-.": beginning-of-line
+"\C-\M-x": end-of-line
+"=": beginning-of-line
+`
+	p := newTestPrompt()
+	if err := OptionInputrc(strings.NewReader(rc))(p); err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if len(p.InputrcWarnings()) != 0 {
+		t.Errorf("Should be empty, but got %#v", p.InputrcWarnings())
+	}
+
+	p.buf.InsertText("echo hello", false, true)
+	p.feed("\x1b\x7f")
+	if text := p.buf.Text(); text != "echo " {
+		t.Errorf("Should be %#v, but got %#v", "echo ", text)
+	}
+	p.feed("\x1b.")
+	if ci := p.buf.Document().CursorIndex(); ci != 0 {
+		t.Errorf("Should be %v, but got %v", 0, ci)
+	}
+	p.feed("\x1b\x18")
+	if ci := p.buf.Document().CursorIndex(); ci != 5 {
+		t.Errorf("Should be %v, but got %v", 5, ci)
+	}
+
+	// a bound character runs its function, the others are still inserted
+	p.feed("=")
+	p.feed("x")
+	if text := p.buf.Text(); text != "xecho " {
+		t.Errorf("Should be %#v, but got %#v", "xecho ", text)
+	}
+}
+
+func TestOptionInputrcWarnings(t *testing.T) {
+	scenarioTable := []struct {
+		rc       string
+		expected []string
+	}{
+		{rc: `"\C-x: end-of-line`, expected: []string{"inputrc:1: missing closing quote"}},
+		{rc: "\nControl-a: no-such-function", expected: []string{"inputrc:2: unknown function 'no-such-function'"}},
+		{rc: "set editing-mode vi", expected: []string{"inputrc:1: unsupported editing-mode 'vi'"}},
+		{rc: "$if mode=emacs", expected: []string{"inputrc:1: missing $endif"}},
+		{rc: "$endif", expected: []string{"inputrc:1: $endif without $if"}},
+		{rc: "$unknown\nControl-a: end-of-line", expected: []string{"inputrc:1: unknown directive '$unknown'"}},
+		{rc: `"%": end-of-line`, expected: []string{`inputrc:1: unknown key "%"`}},
+		{rc: `"\e[<0;1;1M": end-of-line`, expected: []string{`inputrc:1: unknown key sequence "\x1b[<0;1;1M"`}},
+	}
+
+	for _, s := range scenarioTable {
+		p := newTestPrompt()
+		if err := OptionInputrc(strings.NewReader(s.rc))(p); err != nil {
+			t.Errorf("Should be nil, but got %v", err)
+		}
+		if !reflect.DeepEqual(p.InputrcWarnings(), s.expected) {
+			t.Errorf("Should be %#v, but got %#v", s.expected, p.InputrcWarnings())
+		}
+	}
+
+	// the lines after a skipped one are applied
+	p := newTestPrompt()
+	OptionInputrc(strings.NewReader("Control-a: no-such-function\nControl-a: end-of-line"))(p)
+	if fn, _ := p.inputrcBindings.Lookup([]KeyCode{ControlA}); fn == nil {
+		t.Errorf("Should bind the keys after a skipped line")
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("input/output error") }
+
+func TestOptionInputrcReadError(t *testing.T) {
+	err := OptionInputrc(errReader{})(newTestPrompt())
+	if expected := "inputrc: input/output error"; err == nil || err.Error() != expected {
+		t.Errorf("Should be %#v, but got %v", expected, err)
+	}
+}
+
+func TestOptionInputrcFileRecursiveInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inputrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, b, missing := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "missing")
+	ioutil.WriteFile(a, []byte("$include "+b+"\nControl-a: end-of-line\n"), 0644)
+	ioutil.WriteFile(b, []byte("$include "+a+"\n$include "+missing+"\n"), 0644)
+	_, openErr := os.Open(missing)
+
+	p := newTestPrompt()
+	if err := OptionInputrcFile(a)(p); err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	expected := []string{
+		b + ":1: $include: recursive $include of '" + a + "'",
+		b + ":2: $include: " + openErr.Error(),
+	}
+	if !reflect.DeepEqual(p.InputrcWarnings(), expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, p.InputrcWarnings())
+	}
+	if fn, _ := p.inputrcBindings.Lookup([]KeyCode{ControlA}); fn == nil {
+		t.Errorf("Should bind the keys after the includes")
+	}
+}
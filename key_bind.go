@@ -9,6 +9,12 @@ type KeyBind struct {
 	Fn  KeyBindFunc
 }
 
+// SequenceBind binds a sequence of keys (e.g. Ctrl+X Ctrl+E) to an operation.
+type SequenceBind struct {
+	Sequence []KeyCode
+	Fn       KeyBindFunc
+}
+
 // ControlBind binds a specific control sequence to an operation.
 type ControlSequenceBind struct {
 	Sequence ControlSequence
@@ -29,6 +29,21 @@ func beginning_of_line(e *Event) {
 	}
 }
 
+// toggle_beginning_of_line Toggle between the beginning of the line and the previous cursor position
+// (if nothing else was done in between)
+func toggle_beginning_of_line(e *Event) {
+	buf := e.Buffer()
+	doc := buf.Document()
+	buf.flags.toggled = true
+	if col := doc.CursorColumnIndex(); col > 0 {
+		buf.toggleIndex = doc.CursorIndex()
+		buf.CursorLeft(Offset(col))
+	} else if buf.toggleIndex >= 0 {
+		buf.SetCursorIndex(buf.toggleIndex)
+		buf.toggleIndex = -1
+	}
+}
+
 // delete_char Delete character under the cursor
 func delete_char(e *Event) {
 	buf := e.Buffer()
@@ -145,3 +160,54 @@ func yank(e *Event) {
 	buf.InsertText(clipboard, false, true)
 	// TODO: output bracketed paste OFF ("\x1b[?2004l") during rendering
 }
+
+// edit_in_editor Edit the input in an external editor ($VISUAL or $EDITOR)
+func edit_in_editor(e *Event) {
+	e.SetExternalEdit()
+}
+
+// clear_screen Clear the screen, similar to the clear command
+func clear_screen(e *Event) {
+	e.SetClearScreen()
+}
+
+// accept_line Accept the input, as if Enter was pressed
+func accept_line(e *Event) {
+	e.SetEndEdit()
+}
+
+// previous_history Previous command (or line, in a multi-line input)
+func previous_history(e *Event) {
+	e.SetTranslatedKey(KeyControl | KeyP)
+}
+
+// next_history Next command (or line, in a multi-line input)
+func next_history(e *Event) {
+	e.SetTranslatedKey(KeyControl | KeyN)
+}
+
+// keyBindFuncs holds the functions which can be called by name,
+// using readline's command names where there is one.
+var keyBindFuncs = map[string]KeyBindFunc{
+	"beginning-of-line":        beginning_of_line,
+	"end-of-line":              end_of_line,
+	"toggle-beginning-of-line": toggle_beginning_of_line,
+	"forward-char":             forward_char,
+	"backward-char":            backward_char,
+	"forward-word":             forward_word,
+	"backward-word":            backward_word,
+	"delete-char":              delete_char,
+	"backward-delete-char":     backward_delete_char,
+	"kill-word":                kill_word,
+	"backward-kill-word":       backward_kill_word,
+	"unix-word-rubout":         backward_kill_word,
+	"kill-line":                kill_line,
+	"backward-kill-line":       backward_kill_line,
+	"unix-line-discard":        backward_kill_line,
+	"yank":                     yank,
+	"clear-screen":             clear_screen,
+	"accept-line":              accept_line,
+	"previous-history":         previous_history,
+	"next-history":             next_history,
+	"edit-in-editor":           edit_in_editor,
+}
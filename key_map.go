@@ -0,0 +1,169 @@
+package prompt
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultKeySequenceTimeout is how long to wait for the next key of a key sequence.
+const defaultKeySequenceTimeout = 2 * time.Second
+
+// KeyMap binds sequences of keys (e.g. Ctrl+X Ctrl+E) to functions.
+// It is a trie keyed on KeyCode; each node may both be bound itself and be
+// the prefix of longer sequences.
+type KeyMap struct {
+	root keyMapNode
+}
+
+type keyMapNode struct {
+	fn   KeyBindFunc
+	next map[KeyCode]*keyMapNode
+}
+
+// NewKeyMap returns an empty KeyMap.
+func NewKeyMap() *KeyMap {
+	return &KeyMap{}
+}
+
+// Bind binds 'seq' to 'fn', replacing any previous binding of the same sequence.
+// Binding a nil function removes the binding.
+func (m *KeyMap) Bind(seq []KeyCode, fn KeyBindFunc) {
+	if len(seq) == 0 {
+		return
+	}
+	node := &m.root
+	for _, key := range seq {
+		if node.next == nil {
+			node.next = make(map[KeyCode]*keyMapNode)
+		}
+		n, ok := node.next[key]
+		if !ok {
+			n = &keyMapNode{}
+			node.next[key] = n
+		}
+		node = n
+	}
+	node.fn = fn
+}
+
+// Lookup returns the function bound to 'seq' (nil if none),
+// and whether there are longer sequences beginning with 'seq'.
+func (m *KeyMap) Lookup(seq []KeyCode) (fn KeyBindFunc, isPrefix bool) {
+	node := &m.root
+	for _, key := range seq {
+		n, ok := node.next[key]
+		if !ok {
+			return nil, false
+		}
+		node = n
+	}
+	return node.fn, len(node.next) > 0
+}
+
+// keyMapFromBindings returns a KeyMap with the given bindings.
+func keyMapFromBindings(binds []SequenceBind) *KeyMap {
+	m := NewKeyMap()
+	for _, b := range binds {
+		m.Bind(b.Sequence, b.Fn)
+	}
+	return m
+}
+
+// keySequenceName returns e.g. "C-x C-e" for a key sequence.
+func keySequenceName(seq []KeyCode) string {
+	names := make([]string, len(seq))
+	for i, k := range seq {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, " ")
+}
+
+// keyNames holds the names of keys that don't produce a printable character.
+var keyNames = map[KeyCode]string{
+	KeyEscape:    "ESC",
+	KeyTab:       "TAB",
+	KeyEnter:     "RET",
+	KeySpace:     "SPC",
+	KeyBackspace: "DEL",
+	KeyBackTab:   "BackTab",
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyRight:     "Right",
+	KeyLeft:      "Left",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyDelete:    "Delete",
+	KeyInsert:    "Insert",
+	KeyPageUp:    "PageUp",
+	KeyPageDown:  "PageDown",
+	KeyF1:        "F1",
+	KeyF2:        "F2",
+	KeyF3:        "F3",
+	KeyF4:        "F4",
+	KeyF5:        "F5",
+	KeyF6:        "F6",
+	KeyF7:        "F7",
+	KeyF8:        "F8",
+	KeyF9:        "F9",
+	KeyF10:       "F10",
+	KeyF11:       "F11",
+	KeyF12:       "F12",
+	KeyF13:       "F13",
+	KeyF14:       "F14",
+	KeyF15:       "F15",
+	KeyF16:       "F16",
+	KeyF17:       "F17",
+	KeyF18:       "F18",
+	KeyF19:       "F19",
+	KeyF20:       "F20",
+	KeyF21:       "F21",
+	KeyF22:       "F22",
+	KeyF23:       "F23",
+	KeyF24:       "F24",
+}
+
+// keyRune returns the (unshifted) character produced by a key, if any.
+func keyRune(k KeyCode) (rune, bool) {
+	switch {
+	case k >= KeyA && k <= KeyZ:
+		return 'a' + rune(k-KeyA), true
+	case k == Key0:
+		return '0', true
+	case k >= Key1 && k <= Key9:
+		return '1' + rune(k-Key1), true
+	}
+	for r, key := range runeKeys {
+		if key == k && r > ' ' && r != 0x7f {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// keyName returns an emacs style name of the key, e.g. "C-x", "M-f" or "S-Up".
+func keyName(k KeyCode) string {
+	var prefix string
+	if HasControlModifier(k) {
+		prefix += "C-"
+	}
+	if HasAltModifier(k) {
+		prefix += "M-"
+	}
+	base := k &^ (KeyShift | KeyControl | KeyAlt)
+	if r, ok := keyRune(base); ok {
+		if HasShiftModifier(k) && r >= 'a' && r <= 'z' {
+			return prefix + string(r-'a'+'A')
+		}
+		if HasShiftModifier(k) {
+			prefix += "S-"
+		}
+		return prefix + string(r)
+	}
+	if HasShiftModifier(k) {
+		prefix += "S-"
+	}
+	if name, ok := keyNames[base]; ok {
+		return prefix + name
+	}
+	return prefix + "?"
+}
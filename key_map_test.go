@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestKeyMap(t *testing.T) {
+	m := NewKeyMap()
+	called := ""
+	m.Bind([]KeyCode{ControlX, ControlE}, func(*Event) { called = "C-x C-e" })
+	m.Bind([]KeyCode{ControlX}, func(*Event) { called = "C-x" })
+	m.Bind([]KeyCode{ControlA}, func(*Event) { called = "C-a" })
+
+	scenarioTable := []struct {
+		seq      []KeyCode
+		bound    string
+		isPrefix bool
+	}{
+		{seq: []KeyCode{ControlX}, bound: "C-x", isPrefix: true},
+		{seq: []KeyCode{ControlX, ControlE}, bound: "C-x C-e", isPrefix: false},
+		{seq: []KeyCode{ControlX, ControlA}, bound: "", isPrefix: false},
+		{seq: []KeyCode{ControlA}, bound: "C-a", isPrefix: false},
+		{seq: []KeyCode{ControlB}, bound: "", isPrefix: false},
+	}
+
+	for _, s := range scenarioTable {
+		called = ""
+		fn, isPrefix := m.Lookup(s.seq)
+		if fn != nil {
+			fn(nil)
+		}
+		if called != s.bound || isPrefix != s.isPrefix {
+			t.Errorf("%v: Should be %q/%v, but got %q/%v", s.seq, s.bound, s.isPrefix, called, isPrefix)
+		}
+	}
+}
+
+func TestKeySequenceName(t *testing.T) {
+	scenarioTable := []struct {
+		seq      []KeyCode
+		expected string
+	}{
+		{seq: []KeyCode{ControlX, ControlE}, expected: "C-x C-e"},
+		{seq: []KeyCode{KeyAlt | KeyF}, expected: "M-f"},
+		{seq: []KeyCode{KeyShift | KeyUp, KeyEscape}, expected: "S-Up ESC"},
+		{seq: []KeyCode{KeyControl | KeyShift | KeyA}, expected: "C-A"},
+		{seq: []KeyCode{KeySlash, Key7}, expected: "/ 7"},
+	}
+
+	for _, s := range scenarioTable {
+		if name := keySequenceName(s.seq); name != s.expected {
+			t.Errorf("Should be %q, but got %q", s.expected, name)
+		}
+	}
+}
+
+func newTestPrompt() *Prompt {
+	return &Prompt{
+		buf:                  NewBuffer(),
+		renderer:             NewRender("> ", nil),
+		history:              NewHistory(),
+		completion:           NewCompletionManager(func(Document) []Choice { return nil }, 6),
+		editMode:             EmacsMode,
+		keyBindings:          NewKeyMap(),
+		inputrcBindings:      NewKeyMap(),
+		keySequenceTimeoutCh: make(chan int, 1),
+	}
+}
+
+func TestFeedKeySequence(t *testing.T) {
+	p := newTestPrompt()
+	calls := 0
+	OptionBindKeySequence(SequenceBind{
+		Sequence: []KeyCode{ControlX, KeyE},
+		Fn:       func(e *Event) { calls++ },
+	})(p)
+
+	p.feed("\x18") // C-x
+	if calls != 0 || p.renderer.status != "C-x-" {
+		t.Errorf("Should wait for the rest of the sequence, but got calls=%d status=%q", calls, p.renderer.status)
+	}
+	p.feed("e")
+	if calls != 1 || p.renderer.status != "" || p.buf.Text() != "" {
+		t.Errorf("Should run the binding, but got calls=%d status=%q text=%q", calls, p.renderer.status, p.buf.Text())
+	}
+
+	// an unbound sequence is discarded
+	p.feed("\x18")
+	p.feed("q")
+	if calls != 1 || p.buf.Text() != "" {
+		t.Errorf("Should discard the sequence, but got calls=%d text=%q", calls, p.buf.Text())
+	}
+
+	// giving up waiting
+	p.feed("\x18")
+	p.keySequenceTimedOut(p.keySequenceGen)
+	p.feed("e")
+	if calls != 1 || p.buf.Text() != "e" {
+		t.Errorf("Should have timed out, but got calls=%d text=%q", calls, p.buf.Text())
+	}
+}
+
+func TestFeedKeySequencePaste(t *testing.T) {
+	p := newTestPrompt()
+	calls := 0
+	OptionBindKeySequence(SequenceBind{
+		Sequence: []KeyCode{ControlX, KeyE},
+		Fn:       func(e *Event) { calls++ },
+	})(p)
+
+	// text that isn't a key ends the sequence and is inserted
+	p.feed("\x18") // C-x
+	p.feed("hello")
+	if calls != 0 || p.buf.Text() != "hello" {
+		t.Errorf("Should insert the text, but got calls=%d text=%q", calls, p.buf.Text())
+	}
+	if len(p.pendingKeys) != 0 || p.renderer.status != "" {
+		t.Errorf("Should not wait anymore, but got pending=%v status=%q", p.pendingKeys, p.renderer.status)
+	}
+}
+
+func TestToggleBeginningOfLine(t *testing.T) {
+	p := newTestPrompt()
+	p.buf = NewBuffer()
+	p.buf.InsertText("hello", false, true)
+
+	p.feed("\x18")
+	p.feed("\x18") // C-x C-x
+	if ci := p.buf.Document().CursorIndex(); ci != 0 {
+		t.Errorf("Should be %v, but got %v", 0, ci)
+	}
+	p.feed("\x18")
+	p.feed("\x18")
+	if ci := p.buf.Document().CursorIndex(); ci != 5 {
+		t.Errorf("Should be %v, but got %v", 5, ci)
+	}
+
+	// another key in between ends toggling
+	p.feed("\x18")
+	p.feed("\x18")
+	p.feed("\x06") // C-f
+	p.feed("\x02") // C-b
+	p.feed("\x18")
+	p.feed("\x18")
+	if ci := p.buf.Document().CursorIndex(); ci != 0 {
+		t.Errorf("Should be %v, but got %v", 0, ci)
+	}
+}
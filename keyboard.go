@@ -0,0 +1,303 @@
+package prompt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// KeyboardProtocol selects how the terminal is asked to encode modified keys.
+type KeyboardProtocol int
+
+const (
+	// KeyboardLegacy uses whatever the terminal sends by default.
+	KeyboardLegacy KeyboardProtocol = iota
+	// KeyboardModifyOtherKeys enables xterm's modifyOtherKeys (level 2),
+	// which reports e.g. Ctrl+Shift combos as "CSI 27 ; modifier ; code ~".
+	KeyboardModifyOtherKeys
+	// KeyboardKitty enables the kitty keyboard protocol ("disambiguate escape codes"),
+	// which reports modified keys as "CSI code ; modifier u".
+	// This makes it possible to tell e.g. Ctrl+I and Tab apart.
+	KeyboardKitty
+)
+
+const (
+	modifyOtherKeysEnable  = "\x1b[>4;2m"
+	modifyOtherKeysDisable = "\x1b[>4m"
+	kittyKeyboardEnable    = "\x1b[>1u"
+	kittyKeyboardDisable   = "\x1b[<u"
+)
+
+// enableSequence returns the control sequence that turns the protocol on.
+func (k KeyboardProtocol) enableSequence() string {
+	switch k {
+	case KeyboardModifyOtherKeys:
+		return modifyOtherKeysEnable
+	case KeyboardKitty:
+		return kittyKeyboardEnable
+	}
+	return ""
+}
+
+// disableSequence returns the control sequence that turns the protocol off.
+func (k KeyboardProtocol) disableSequence() string {
+	switch k {
+	case KeyboardModifyOtherKeys:
+		return modifyOtherKeysDisable
+	case KeyboardKitty:
+		return kittyKeyboardDisable
+	}
+	return ""
+}
+
+// modifier bits as encoded in the CSI parameter (value - 1).
+const (
+	csiModShift = 1 << iota
+	csiModAlt
+	csiModControl
+	csiModSuper
+	csiModHyper
+	csiModMeta
+)
+
+// csiTildeKeys maps the first parameter of "CSI n ~" sequences.
+var csiTildeKeys = map[int]KeyCode{
+	1:  KeyHome,
+	2:  KeyInsert,
+	3:  KeyDelete,
+	4:  KeyEnd,
+	5:  KeyPageUp,
+	6:  KeyPageDown,
+	7:  KeyHome,
+	8:  KeyEnd,
+	11: KeyF1,
+	12: KeyF2,
+	13: KeyF3,
+	14: KeyF4,
+	15: KeyF5,
+	17: KeyF6,
+	18: KeyF7,
+	19: KeyF8,
+	20: KeyF9,
+	21: KeyF10,
+	23: KeyF11,
+	24: KeyF12,
+	25: KeyF13,
+	26: KeyF14,
+	28: KeyF15,
+	29: KeyF16,
+	31: KeyF17,
+	32: KeyF18,
+	33: KeyF19,
+	34: KeyF20,
+}
+
+// csiLetterKeys maps the final byte of "CSI 1 ; m X" and "SS3 X" sequences.
+var csiLetterKeys = map[byte]KeyCode{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'P': KeyF1,
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+	'Z': KeyBackTab,
+	'E': Ignore, // keypad '5'
+}
+
+// runeKeys maps non-letter, non-digit code points to a key.
+var runeKeys = map[rune]KeyCode{
+	'\t':   KeyTab,
+	'\r':   KeyEnter,
+	'\n':   KeyEnter,
+	'\x1b': KeyEscape,
+	'\x08': KeyBackspace,
+	'\x7f': KeyBackspace,
+	' ':    KeySpace,
+	'`':    KeyBacktick,
+	'^':    KeyCircumflex,
+	'_':    KeyUnderscore,
+	'-':    KeyMinus,
+	'=':    KeyEquals,
+	'[':    KeySquareOpen,
+	']':    KeySquareClose,
+	'\'':   KeySingleQuote,
+	'\\':   KeyBackslash,
+	'<':    KeyLessThan,
+	',':    KeyComma,
+	'.':    KeyPoint,
+	'/':    KeySlash,
+}
+
+// keyFromRune returns the key which produces the code point 'r' (ignoring any modifiers),
+// or Undefined if there is no such key.
+func keyFromRune(r rune) KeyCode {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return KeyA + KeyCode(r-'a')
+	case r >= 'A' && r <= 'Z':
+		return KeyShift | (KeyA + KeyCode(r-'A'))
+	case r == '0':
+		return Key0
+	case r >= '1' && r <= '9':
+		return Key1 + KeyCode(r-'1')
+	}
+	if k, ok := runeKeys[r]; ok {
+		return k
+	}
+	return Undefined
+}
+
+// decodeModifiers translates a CSI modifier parameter to key modifier bits.
+func decodeModifiers(param int) KeyCode {
+	if param <= 1 {
+		return 0
+	}
+	bits := param - 1
+	var mod KeyCode
+	if bits&csiModShift != 0 {
+		mod |= KeyShift
+	}
+	if bits&(csiModAlt|csiModMeta|csiModSuper) != 0 {
+		mod |= KeyAlt
+	}
+	if bits&csiModControl != 0 {
+		mod |= KeyControl
+	}
+	return mod
+}
+
+// parseCSI splits a "CSI params final" sequence into its numeric parameters and final byte.
+// Sub-parameters (separated by ':') are dropped, only the first one of each parameter is kept.
+func parseCSI(cs ControlSequence) (params []int, final byte, ok bool) {
+	s := string(cs)
+	if len(s) < 3 || !strings.HasPrefix(s, "\x1b[") {
+		return nil, 0, false
+	}
+	final = s[len(s)-1]
+	if final < 0x40 || final > 0x7e {
+		return nil, 0, false
+	}
+	body := s[2 : len(s)-1]
+	if body == "" {
+		return nil, final, true
+	}
+	for _, field := range strings.Split(body, ";") {
+		if i := strings.IndexByte(field, ':'); i >= 0 {
+			field = field[:i]
+		}
+		if field == "" {
+			params = append(params, 0)
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, 0, false
+		}
+		params = append(params, n)
+	}
+	return params, final, true
+}
+
+// decodeKey decodes modifier-aware key sequences that are not listed in KeySequences, i.e.:
+//
+//	CSI 1 ; m {ABCDHFPQRS}     xterm style modified cursor & function keys
+//	CSI n ; m ~                modified editing & function keys
+//	CSI 27 ; m ; code ~        xterm modifyOtherKeys
+//	CSI code ; m u             kitty keyboard protocol / "fixterms"
+//	SS3 {ABCDHFPQRS}           application mode cursor & function keys
+func decodeKey(cs ControlSequence) (KeyCode, bool) {
+	if len(cs) == 3 && strings.HasPrefix(string(cs), "\x1bO") {
+		k, ok := csiLetterKeys[cs[2]]
+		return k, ok
+	}
+
+	params, final, ok := parseCSI(cs)
+	if !ok {
+		return Undefined, false
+	}
+	param := func(i, def int) int {
+		if i < len(params) && params[i] != 0 {
+			return params[i]
+		}
+		return def
+	}
+
+	switch final {
+	case '~':
+		if param(0, 0) == 27 && len(params) >= 3 { // modifyOtherKeys
+			return decodeCodepoint(rune(params[2]), param(1, 1))
+		}
+		k, ok := csiTildeKeys[param(0, 0)]
+		if !ok {
+			return Undefined, false
+		}
+		return k | decodeModifiers(param(1, 1)), true
+
+	case 'u':
+		if len(params) == 0 {
+			return Undefined, false
+		}
+		return decodeCodepoint(rune(params[0]), param(1, 1))
+
+	default:
+		k, ok := csiLetterKeys[final]
+		if !ok || param(0, 1) != 1 {
+			return Undefined, false
+		}
+		if k == Ignore {
+			return k, true
+		}
+		return k | decodeModifiers(param(1, 1)), true
+	}
+}
+
+// decodeCodepoint returns the key for a code point reported with a modifier parameter.
+// Printable code points without Control or Alt are text, not keys (see keyText).
+func decodeCodepoint(r rune, modParam int) (KeyCode, bool) {
+	mod := decodeModifiers(modParam)
+	if mod&^KeyShift == 0 && r >= ' ' && r != 0x7f {
+		return Undefined, true
+	}
+	k := keyFromRune(r)
+	if k == Undefined {
+		return Undefined, false
+	}
+	return k | mod, true
+}
+
+// keyText returns the text a key sequence should insert when it's not bound to anything.
+// For plain input this is the sequence itself, for a code point reported via
+// modifyOtherKeys or the kitty protocol (without Control or Alt) it's that code point.
+// Other escape sequences insert nothing.
+func keyText(cs ControlSequence) string {
+	if len(cs) < 2 || cs[0] != 0x1b {
+		return string(cs)
+	}
+	params, final, ok := parseCSI(cs)
+	if !ok {
+		return string(cs)
+	}
+	var r rune
+	var mod int
+	switch {
+	case final == 'u' && len(params) > 0:
+		r = rune(params[0])
+		if len(params) > 1 {
+			mod = params[1]
+		}
+	case final == '~' && len(params) >= 3 && params[0] == 27:
+		r, mod = rune(params[2]), params[1]
+	default:
+		return ""
+	}
+	if decodeModifiers(mod)&^KeyShift != 0 || r < ' ' || r == 0x7f {
+		return ""
+	}
+	if mod > 1 && (mod-1)&csiModShift != 0 && r >= 'a' && r <= 'z' {
+		r -= 'a' - 'A'
+	}
+	return string(r)
+}
@@ -0,0 +1,146 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestFindKeyModified(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		input    ControlSequence
+		expected KeyCode
+	}{
+		{
+			name:     "listed sequence",
+			input:    "\x1b[1;5A",
+			expected: KeyControl | KeyUp,
+		},
+		{
+			name:     "alt+shift arrow",
+			input:    "\x1b[1;4D",
+			expected: KeyAlt | KeyShift | KeyLeft,
+		},
+		{
+			name:     "control+alt home",
+			input:    "\x1b[1;7H",
+			expected: KeyControl | KeyAlt | KeyHome,
+		},
+		{
+			name:     "control F5",
+			input:    "\x1b[15;5~",
+			expected: KeyControl | KeyF5,
+		},
+		{
+			name:     "control F1",
+			input:    "\x1b[1;5P",
+			expected: KeyControl | KeyF1,
+		},
+		{
+			name:     "control+shift page down",
+			input:    "\x1b[6;6~",
+			expected: KeyControl | KeyShift | KeyPageDown,
+		},
+		{
+			name:     "SS3 arrow",
+			input:    "\x1bOA",
+			expected: KeyUp,
+		},
+		{
+			name:     "modifyOtherKeys control+shift+a",
+			input:    "\x1b[27;6;65~",
+			expected: KeyControl | KeyShift | KeyA,
+		},
+		{
+			name:     "kitty control+i",
+			input:    "\x1b[105;5u",
+			expected: KeyControl | KeyI,
+		},
+		{
+			name:     "kitty control+shift+x",
+			input:    "\x1b[120;6u",
+			expected: KeyControl | KeyShift | KeyX,
+		},
+		{
+			name:     "kitty escape",
+			input:    "\x1b[27u",
+			expected: KeyEscape,
+		},
+		{
+			name:     "kitty alt+enter",
+			input:    "\x1b[13;3u",
+			expected: KeyAlt | KeyEnter,
+		},
+		{
+			name:     "kitty shifted text is not a key",
+			input:    "\x1b[97;2u",
+			expected: Undefined,
+		},
+		{
+			name:     "tab",
+			input:    "\t",
+			expected: KeyTab,
+		},
+		{
+			name:     "unknown",
+			input:    "\x1b[99;5~",
+			expected: Undefined,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			key := FindKey(s.input)
+			if key != s.expected {
+				t.Errorf("Expected %#x, but got %#x", s.expected, key)
+			}
+		})
+	}
+}
+
+func TestKeyText(t *testing.T) {
+	scenarioTable := []struct {
+		input    ControlSequence
+		expected string
+	}{
+		{input: "abc", expected: "abc"},
+		{input: "\x1b[97;2u", expected: "A"},
+		{input: "\x1b[33;2u", expected: "!"},
+		{input: "\x1b[27;2;64~", expected: "@"},
+		{input: "\x1b[105;5u", expected: ""},
+		{input: "\x1b[1;5A", expected: ""},
+	}
+
+	for _, s := range scenarioTable {
+		if text := keyText(s.input); text != s.expected {
+			t.Errorf("%q: Should be %q, but got %q", s.input, s.expected, text)
+		}
+	}
+}
+
+func TestFeedLateCursorPositionReport(t *testing.T) {
+	scenarioTable := []struct {
+		name     string
+		protocol KeyboardProtocol
+		expected int
+	}{
+		{name: "legacy drops the report", protocol: KeyboardLegacy, expected: 0},
+		{name: "kitty decodes C-F3", protocol: KeyboardKitty, expected: 1},
+	}
+
+	for _, s := range scenarioTable {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt()
+			calls := 0
+			OptionKeyboardProtocol(s.protocol)(p)
+			OptionBindKey(KeyBind{Key: KeyControl | KeyF3, Fn: func(*Event) { calls++ }})(p)
+
+			p.feed("\x1b[1;5R")
+			if calls != s.expected {
+				t.Errorf("Should be %v, but got %v", s.expected, calls)
+			}
+			if text := p.buf.Text(); text != "" {
+				t.Errorf("Should be %q, but got %q", "", text)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// textLayout is where the prefixes and the characters of a document are displayed,
+// with rows wrapped at the terminal width the same way as in a frame (see place).
+// Positions are relative to the prompt's home position.
+type textLayout struct {
+	width Column
+	text  []rune
+	runes []Coord // where each rune is displayed; a line feed is at the end of its line
+	lines []lineLayout
+}
+
+type lineLayout struct {
+	start, end Index // the runes of the line
+	home       Coord // where the line (its prefix) starts
+	textStart  Coord // after the prefix
+	textEnd    Coord // after the last rune; X may be the width
+}
+
+// layoutText lays out the text of 'd', with 'prefix' (if not nil) before each line.
+func layoutText(d *Document, width Column, prefix func(doc *Document, row Row) StyledText) *textLayout {
+	l := &textLayout{
+		width: width,
+		text:  d.text,
+		runes: make([]Coord, 0, len(d.text)),
+	}
+	var pos Coord
+	var index Index
+	for row, rtext := range d.lines() {
+		if row > 0 {
+			pos = Coord{0, pos.Y + 1}
+			l.runes = append(l.runes, l.lines[row-1].textEnd) // the line feed
+			index++
+		}
+		line := lineLayout{start: index, home: pos}
+		if prefix != nil {
+			pos = walkStyledText(pos, width, prefix(d, Row(row)), Style{}, nil)
+		}
+		line.textStart = pos
+		for _, r := range rtext {
+			var at Coord
+			at, pos = place(pos, Column(runewidth.RuneWidth(r)), width)
+			l.runes = append(l.runes, at)
+		}
+		index += len(rtext)
+		line.end, line.textEnd = index, pos
+		l.lines = append(l.lines, line)
+	}
+	return l
+}
+
+// wrap returns 'pos' moved to the start of the next row if it's past the end of a row.
+func (l *textLayout) wrap(pos Coord) Coord {
+	if pos.X >= l.width {
+		return Coord{0, pos.Y + 1}
+	}
+	return pos
+}
+
+// cursor returns where the cursor is displayed when it's at 'index'.
+func (l *textLayout) cursor(index Index) Coord {
+	if index < len(l.runes) {
+		return l.wrap(l.runes[index])
+	}
+	return l.wrap(l.lines[len(l.lines)-1].textEnd)
+}
+
+// rows returns the number of rows used, including one for the cursor after a full last row.
+func (l *textLayout) rows() Row {
+	return l.wrap(l.lines[len(l.lines)-1].textEnd).Y + 1
+}
+
+// index returns the index of the rune displayed at 'pos' (see Document.TranslateDisplayCoordToIndex).
+func (l *textLayout) index(pos Coord) (index Index, ok bool) {
+	for _, line := range l.lines {
+		for i := line.start; i < line.end; i++ {
+			at := l.runes[i]
+			if at.Y > pos.Y || (at.Y == pos.Y && at.X+Column(runewidth.RuneWidth(l.text[i])) > pos.X) {
+				return i, true
+			}
+		}
+		if pos.Y <= l.wrap(line.textEnd).Y {
+			return line.end, true
+		}
+	}
+	return len(l.text), false
+}
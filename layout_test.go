@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestLayoutTextCursor(t *testing.T) {
+	prefix := func(doc *Document, row Row) StyledText {
+		if row == 0 {
+			return StyledText{{Text: "> "}}
+		}
+		return StyledText{{Text: ". "}}
+	}
+	scenarioTable := []struct {
+		text     string
+		cursor   int
+		expected Coord
+		rows     Row
+	}{
+		{text: "abc", cursor: 3, expected: Coord{5, 0}, rows: 1},
+		{text: "abcdefgh", cursor: 8, expected: Coord{0, 1}, rows: 2},
+		{text: "abcdefgh", cursor: 7, expected: Coord{9, 0}, rows: 2},
+		{text: "abcdefghijklmnopqrstuvwxyz", cursor: 26, expected: Coord{8, 2}, rows: 3},
+		{text: "abcdefghijklmnopqrstuvwxyz", cursor: 10, expected: Coord{2, 1}, rows: 3},
+		// a wide character that doesn't fit goes on the next row
+		{text: "abcdefg日本", cursor: 7, expected: Coord{0, 1}, rows: 2},
+		{text: "abcdefg日本", cursor: 9, expected: Coord{4, 1}, rows: 2},
+		{text: "abcdefghijkl\nxy", cursor: 12, expected: Coord{4, 1}, rows: 3},
+		{text: "abcdefghijkl\nxy", cursor: 15, expected: Coord{4, 2}, rows: 3},
+		{text: "abcdefgh\nxy", cursor: 8, expected: Coord{0, 1}, rows: 2},
+		{text: "ab\n\n", cursor: 4, expected: Coord{2, 2}, rows: 3},
+	}
+
+	for _, s := range scenarioTable {
+		l := layoutText(NewDocument(s.text, s.cursor), 10, prefix)
+		if c := l.cursor(s.cursor); c != s.expected {
+			t.Errorf("%#v at %d: Should be %v, but got %v", s.text, s.cursor, s.expected, c)
+		}
+		if rows := l.rows(); rows != s.rows {
+			t.Errorf("%#v: Should be %v rows, but got %v", s.text, s.rows, rows)
+		}
+	}
+}
+
+func TestLayoutTextIndex(t *testing.T) {
+	// every character is found where it's displayed
+	for _, text := range []string{"abcdefghijklmnopqrstuvwxyz", "abcdefg日本語\nxyz", "日本語のテキスト\n\nabc"} {
+		doc := NewDocument(text, 0)
+		l := layoutText(doc, 10, func(*Document, Row) StyledText { return StyledText{{Text: "> "}} })
+		for i, r := range doc.text {
+			if r == '\n' {
+				continue
+			}
+			if index, ok := l.index(l.runes[i]); index != i || !ok {
+				t.Errorf("%#v at %v: Should be %v, but got %v (%v)", text, l.runes[i], i, index, ok)
+			}
+		}
+	}
+}
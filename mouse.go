@@ -0,0 +1,188 @@
+package prompt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MouseButton identifies the button of a mouse event.
+type MouseButton int
+
+const (
+	MouseNone MouseButton = iota
+	MouseLeft
+	MouseMiddle
+	MouseRight
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// MouseAction tells what happened with the button of a mouse event.
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseMotion
+)
+
+// MouseEvent is a decoded mouse report.
+type MouseEvent struct {
+	Button MouseButton
+	Action MouseAction
+	// Pos is the (0-based) screen position of the mouse pointer.
+	Pos Coord
+	// Modifiers are the modifier keys held (KeyShift, KeyAlt and/or KeyControl).
+	Modifiers KeyCode
+}
+
+const (
+	mouseTrackingEnable  = "\x1b[?1000h\x1b[?1006h" // normal tracking, SGR encoding
+	mouseTrackingDisable = "\x1b[?1006l\x1b[?1000l"
+)
+
+// parseMouseEvent decodes an SGR mouse report, i.e. "CSI < b ; x ; y M" (or 'm' when released).
+func parseMouseEvent(cs ControlSequence) (ev MouseEvent, ok bool) {
+	s := string(cs)
+	if len(s) < 9 || !strings.HasPrefix(s, "\x1b[<") {
+		return ev, false
+	}
+	final := s[len(s)-1]
+	if final != 'M' && final != 'm' {
+		return ev, false
+	}
+	fields := strings.Split(s[3:len(s)-1], ";")
+	if len(fields) != 3 {
+		return ev, false
+	}
+	var n [3]int
+	for i, f := range fields {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return ev, false
+		}
+		n[i] = v
+	}
+	b := n[0]
+
+	if b&4 != 0 {
+		ev.Modifiers |= KeyShift
+	}
+	if b&8 != 0 {
+		ev.Modifiers |= KeyAlt
+	}
+	if b&16 != 0 {
+		ev.Modifiers |= KeyControl
+	}
+
+	switch {
+	case b&64 != 0: // wheel
+		if b&1 == 0 {
+			ev.Button = MouseWheelUp
+		} else {
+			ev.Button = MouseWheelDown
+		}
+	case b&3 == 0:
+		ev.Button = MouseLeft
+	case b&3 == 1:
+		ev.Button = MouseMiddle
+	case b&3 == 2:
+		ev.Button = MouseRight
+	}
+
+	switch {
+	case b&32 != 0:
+		ev.Action = MouseMotion
+	case final == 'm':
+		ev.Action = MouseRelease
+	default:
+		ev.Action = MousePress
+	}
+
+	ev.Pos = Coord{Column(n[1] - 1), Row(n[2] - 1)}
+	return ev, true
+}
+
+// parseCPR decodes a cursor position report, i.e. "CSI row ; col R",
+// returning the (0-based) cursor position.
+func parseCPR(cs ControlSequence) (pos Coord, ok bool) {
+	params, final, ok := parseCSI(cs)
+	if !ok || final != 'R' || len(params) != 2 {
+		return pos, false
+	}
+	return Coord{Column(params[1] - 1), Row(params[0] - 1)}, true
+}
+
+// area is a rectangle on screen, relative to the prompt's home position.
+type area struct {
+	origin Coord
+	width  Column
+	height Row
+}
+
+func (a area) contains(c Coord) bool {
+	return c.X >= a.origin.X && c.X < a.origin.X+a.width &&
+		c.Y >= a.origin.Y && c.Y < a.origin.Y+a.height
+}
+
+// handleMouse reacts to a mouse report.
+// Wheel events scroll the completion menu. Clicks need to know where the prompt
+// is on screen, so a cursor position report is requested and the click is handled
+// when that arrives (see handleCursorPositionReport).
+func (p *Prompt) handleMouse(ev MouseEvent) {
+	switch ev.Button {
+	case MouseWheelUp:
+		p.completion.Scroll(-1)
+	case MouseWheelDown:
+		p.completion.Scroll(1)
+	case MouseLeft:
+		if ev.Action != MousePress {
+			return
+		}
+		p.pendingClicks = append(p.pendingClicks, ev)
+		p.cprPending++
+		p.renderer.requestCursorPosition()
+	}
+}
+
+// handleCursorPositionReport resolves any pending clicks now that the cursor position is known
+// (or records the cursor position for the terminal probe).
+func (p *Prompt) handleCursorPositionReport(cursor Coord) {
+	if p.probing && !p.terminalInfo.CursorKnown {
+		// the answer to the probe, which was requested first
+		p.terminalInfo.Cursor, p.terminalInfo.CursorKnown = cursor, true
+		return
+	}
+
+	// the prompt's home position on screen
+	home := cursor.Diff(p.renderer.previousCursor)
+
+	clicks := p.pendingClicks
+	p.pendingClicks = nil
+	for _, ev := range clicks {
+		p.handleClick(ev.Pos.Diff(home))
+	}
+}
+
+// handleClick handles a left click at 'pos' (relative to the prompt's home position).
+func (p *Prompt) handleClick(pos Coord) {
+	if menu := p.renderer.completionArea; menu.contains(pos) {
+		index := p.renderer.completionFirst + int(pos.Y-menu.origin.Y)
+		if p.completion.selected == index {
+			p.acceptCompletion()
+		} else {
+			p.completion.Select(index)
+		}
+		return
+	}
+
+	if pos.Y < 0 {
+		return
+	}
+	doc := p.buf.Document()
+	pos.Y += p.renderer.viewportTop
+	if index, ok := layoutText(doc, p.renderer.termWidth, p.renderer.linePrefix).index(pos); ok {
+		p.completion.Reset()
+		p.buf.SetCursorIndex(index)
+	}
+}
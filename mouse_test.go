@@ -0,0 +1,125 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMouseEvent(t *testing.T) {
+	scenarioTable := []struct {
+		input    ControlSequence
+		expected MouseEvent
+		ok       bool
+	}{
+		{
+			input:    "\x1b[<0;10;5M",
+			expected: MouseEvent{Button: MouseLeft, Action: MousePress, Pos: Coord{9, 4}},
+			ok:       true,
+		},
+		{
+			input:    "\x1b[<0;10;5m",
+			expected: MouseEvent{Button: MouseLeft, Action: MouseRelease, Pos: Coord{9, 4}},
+			ok:       true,
+		},
+		{
+			input:    "\x1b[<65;1;1M",
+			expected: MouseEvent{Button: MouseWheelDown, Action: MousePress, Pos: Coord{0, 0}},
+			ok:       true,
+		},
+		{
+			input:    "\x1b[<18;3;4M",
+			expected: MouseEvent{Button: MouseRight, Action: MousePress, Pos: Coord{2, 3}, Modifiers: KeyControl},
+			ok:       true,
+		},
+		{
+			input: "\x1b[1;5A",
+			ok:    false,
+		},
+	}
+
+	for _, s := range scenarioTable {
+		ev, ok := parseMouseEvent(s.input)
+		if ok != s.ok {
+			t.Errorf("%q: Should be %v, but got %v", s.input, s.ok, ok)
+		} else if ok && ev != s.expected {
+			t.Errorf("%q: Should be %+v, but got %+v", s.input, s.expected, ev)
+		}
+	}
+}
+
+func TestSplitSequences(t *testing.T) {
+	scenarioTable := []struct {
+		input    string
+		expected []ControlSequence
+	}{
+		{
+			input:    "hello world",
+			expected: []ControlSequence{"hello world"},
+		},
+		{
+			input:    "\x1b[<0;10;5M\x1b[<0;10;5m",
+			expected: []ControlSequence{"\x1b[<0;10;5M", "\x1b[<0;10;5m"},
+		},
+		{
+			input:    "ab\x1b[12;1Rcd",
+			expected: []ControlSequence{"ab", "\x1b[12;1R", "cd"},
+		},
+		{
+			input:    "\x1bOA\x1bb\x1b",
+			expected: []ControlSequence{"\x1bOA", "\x1bb", "\x1b"},
+		},
+		{
+			input:    "\x1bOPA", // Linux console F1, listed as a whole
+			expected: []ControlSequence{"\x1bOPA"},
+		},
+		{
+			input:    "\x1b[1;5R\x1bP>|XTerm(367)\x1b\\\x1b[?62;22c",
+			expected: []ControlSequence{"\x1b[1;5R", "\x1bP>|XTerm(367)\x1b\\", "\x1b[?62;22c"},
+		},
+		{
+			input:    "\x1bPab",
+			expected: []ControlSequence{"\x1bP", "ab"},
+		},
+	}
+
+	for _, s := range scenarioTable {
+		if actual := splitSequences([]byte(s.input)); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%q: Should be %q, but got %q", s.input, s.expected, actual)
+		}
+	}
+}
+
+func TestTranslateDisplayCoordToIndex(t *testing.T) {
+	prefix := func(doc *Document, row Row) string {
+		if row == 0 {
+			return "> "
+		}
+		return ". "
+	}
+
+	scenarioTable := []struct {
+		text     string
+		pos      Coord
+		expected Index
+		ok       bool
+	}{
+		{text: "hello", pos: Coord{0, 0}, expected: 0, ok: true},
+		{text: "hello", pos: Coord{4, 0}, expected: 2, ok: true},
+		{text: "hello", pos: Coord{30, 0}, expected: 5, ok: true},
+		{text: "hello", pos: Coord{3, 1}, expected: 5, ok: false},
+		{text: "日本語", pos: Coord{5, 0}, expected: 1, ok: true},
+		{text: "日本語", pos: Coord{6, 0}, expected: 2, ok: true},
+		{text: "ab\ncd", pos: Coord{3, 1}, expected: 4, ok: true},
+		// wrapped at a terminal width of 10
+		{text: "abcdefghijklmn", pos: Coord{2, 1}, expected: 10, ok: true},
+		{text: "abcdefghijklmn\nx", pos: Coord{2, 2}, expected: 15, ok: true},
+	}
+
+	for _, s := range scenarioTable {
+		doc := NewDocument(s.text, 0)
+		index, ok := doc.TranslateDisplayCoordToIndex(10, prefix, s.pos)
+		if index != s.expected || ok != s.ok {
+			t.Errorf("%q at %v: Should be %d/%v, but got %d/%v", s.text, s.pos, s.expected, s.ok, index, ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	isatty "github.com/mattn/go-isatty"
+)
+
+// Interactivity selects whether the prompt uses the terminal,
+// or just reads lines from stdin (e.g. when run in a pipeline).
+type Interactivity int
+
+const (
+	// InteractiveAuto is interactive if both stdin and stdout are terminals,
+	// or if a custom ConsoleParser or ConsoleWriter is used.
+	InteractiveAuto Interactivity = iota
+	// InteractiveAlways always uses the terminal.
+	InteractiveAlways
+	// InteractiveNever reads lines from stdin, without any editing, completion or escape sequences.
+	InteractiveNever
+)
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// isInteractive returns whether to use the terminal (otherwise lines are read from stdin).
+func (p *Prompt) isInteractive() bool {
+	switch p.interactivity {
+	case InteractiveAlways:
+		return true
+	case InteractiveNever:
+		return false
+	}
+	return p.customIO || (isTerminal(os.Stdin) && isTerminal(os.Stdout))
+}
+
+// lineReader reads lines in a goroutine, so waiting for one can be canceled
+// without losing it; the next reader gets it instead.
+type lineReader struct {
+	lines chan string
+	err   error // set when lines is closed
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	lr := &lineReader{
+		lines: make(chan string),
+	}
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				lr.lines <- strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			}
+			if err != nil {
+				lr.err = err
+				close(lr.lines)
+				return
+			}
+		}
+	}()
+	return lr
+}
+
+// readLine returns the next line, ErrEOF at the end of input, or ctx.Err().
+func (lr *lineReader) readLine(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	select {
+	case line, ok := <-lr.lines:
+		if !ok {
+			if lr.err != io.EOF {
+				return "", lr.err
+			}
+			return "", ErrEOF
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+var (
+	stdinLines     *lineReader
+	stdinLinesOnce sync.Once
+)
+
+// lineInput returns the reader of lines in non-interactive mode (stdin, shared by all prompts).
+func (p *Prompt) lineInput() *lineReader {
+	if p.lines == nil {
+		stdinLinesOnce.Do(func() {
+			stdinLines = newLineReader(os.Stdin)
+		})
+		p.lines = stdinLines
+	}
+	return p.lines
+}
+
+// runLines is RunContext in non-interactive mode; each line is executed.
+func (p *Prompt) runLines(ctx context.Context) (exitCode int, err error) {
+	for {
+		line, err := p.lineInput().readLine(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if res := p.execute(line); res.Exit {
+			return res.ExitCode, nil
+		}
+	}
+}
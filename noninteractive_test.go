@@ -0,0 +1,60 @@
+package prompt
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunContextNonInteractive(t *testing.T) {
+	var executed []string
+	p := New(nil, func(Document) []Choice { return nil },
+		OptionInteractive(InteractiveNever),
+		OptionResultExecutor(func(in string) ExecResult {
+			executed = append(executed, in)
+			return ExecResult{Exit: in == "exit", ExitCode: 2}
+		}),
+	)
+	p.lines = newLineReader(strings.NewReader("one\r\n\ntwo\nexit\nnever\n"))
+
+	code, err := p.RunContext(context.Background())
+	if code != 2 || err != nil {
+		t.Errorf("Should be %v (%v), but got %v (%v)", 2, nil, code, err)
+	}
+	if expected := []string{"one", "", "two", "exit"}; !reflect.DeepEqual(executed, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, executed)
+	}
+}
+
+func TestInputContextNonInteractive(t *testing.T) {
+	p := New(nil, func(Document) []Choice { return nil }, OptionInteractive(InteractiveNever))
+	p.lines = newLineReader(strings.NewReader("one\ntwo"))
+
+	// a canceled read doesn't lose the line
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.InputContext(ctx); err != context.Canceled {
+		t.Errorf("Should be %v, but got %v", context.Canceled, err)
+	}
+
+	for _, expected := range []string{"one", "two"} {
+		if text, err := p.InputContext(context.Background()); text != expected || err != nil {
+			t.Errorf("Should be %#v (%v), but got %#v (%v)", expected, nil, text, err)
+		}
+	}
+	if _, err := p.InputContext(context.Background()); err != ErrEOF {
+		t.Errorf("Should be %v, but got %v", ErrEOF, err)
+	}
+}
+
+func TestIsInteractive(t *testing.T) {
+	if p := newInputTestPrompt(newTestParser()); !p.isInteractive() {
+		t.Errorf("Should be interactive with a custom parser")
+	}
+	p := newInputTestPrompt(newTestParser())
+	OptionInteractive(InteractiveNever)(p)
+	if p.isInteractive() {
+		t.Errorf("Should not be interactive with InteractiveNever")
+	}
+}
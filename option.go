@@ -1,6 +1,10 @@
 package prompt
 
-import "os"
+import (
+	"io"
+	"os"
+	"time"
+)
 
 // Option is the type to replace default parameters.
 // prompt.New accepts any number of options (this is functional option pattern).
@@ -10,6 +14,7 @@ type Option func(prompt *Prompt) error
 func OptionParser(x ConsoleParser) Option {
 	return func(p *Prompt) error {
 		p.in = x
+		p.customIO = true
 		return nil
 	}
 }
@@ -19,6 +24,7 @@ func OptionWriter(x ConsoleWriter) Option {
 	return func(p *Prompt) error {
 		registerConsoleWriter(x)
 		p.renderer.out = x
+		p.customIO = true
 		return nil
 	}
 }
@@ -34,7 +40,7 @@ func OptionTitle(x string) Option {
 // OptionPrefix to set (a fixed) prefix string.
 func OptionPrefix(x string) Option {
 	return func(p *Prompt) error {
-		p.renderer.prefix = x
+		p.renderer.prefix = plainText(x)
 		// TODO: proposal for the renderer to always use 'prefixCallback',
 		//   it might even be possible to use 'prefixCallback' in place of 'getPrefix'
 		//p.renderer.prefixCallback = func(doc *Document, row Row) (string, bool) {
@@ -52,7 +58,7 @@ func OptionPrefix(x string) Option {
 // OptionContinuationPrefix to set (a fixed) continuation prefix string.
 func OptionContinuationPrefix(x string) Option {
 	return func(p *Prompt) error {
-		p.renderer.continuationPrefix = x
+		p.renderer.continuationPrefix = plainText(x)
 		// TODO: proposal for the renderer to always use 'prefixCallback',
 		//   it might even be possible to use 'prefixCallback' in place of 'getPrefix'
 		//p.renderer.prefixCallback = func(doc *Document, row Row) (string, bool) {
@@ -67,8 +73,34 @@ func OptionContinuationPrefix(x string) Option {
 	}
 }
 
-// OptionSuffix to set (a fixed) suffix string.
+// OptionSuffix to set (a fixed) suffix string, shown right-aligned on the first row (like a zsh RPROMPT).
+// It's hidden while the input would overlap it, and removed when the input is accepted.
 func OptionSuffix(x string) Option {
+	return func(p *Prompt) error {
+		p.renderer.suffix = plainText(x)
+		return nil
+	}
+}
+
+// OptionStyledPrefix to set a prefix made of segments with their own colors (e.g. a powerline-style prompt).
+// Nil colors are the prefix colors.
+func OptionStyledPrefix(x StyledText) Option {
+	return func(p *Prompt) error {
+		p.renderer.prefix = x
+		return nil
+	}
+}
+
+// OptionStyledContinuationPrefix to set a continuation prefix made of styled segments.
+func OptionStyledContinuationPrefix(x StyledText) Option {
+	return func(p *Prompt) error {
+		p.renderer.continuationPrefix = x
+		return nil
+	}
+}
+
+// OptionStyledSuffix to set a suffix made of styled segments (see OptionSuffix).
+func OptionStyledSuffix(x StyledText) Option {
 	return func(p *Prompt) error {
 		p.renderer.suffix = x
 		return nil
@@ -77,14 +109,36 @@ func OptionSuffix(x string) Option {
 
 // OptionLivePrefix to change the prefix (and continuation) dynamically by callback function.
 func OptionLivePrefix(f func(doc *Document, row Row) (prefix string, usePrefix bool)) Option {
+	return func(p *Prompt) error {
+		p.renderer.prefixCallback = func(doc *Document, row Row) (StyledText, bool) {
+			prefix, ok := f(doc, row)
+			return plainText(prefix), ok
+		}
+		return nil
+	}
+}
+
+// OptionLiveStyledPrefix to change the prefix (and continuation) dynamically, like OptionLivePrefix, with styled segments.
+func OptionLiveStyledPrefix(f func(doc *Document, row Row) (prefix StyledText, usePrefix bool)) Option {
 	return func(p *Prompt) error {
 		p.renderer.prefixCallback = f
 		return nil
 	}
 }
 
-// OptionLiveSuffix to change the suffix dynamically by callback function.
+// OptionLiveSuffix to change the suffix dynamically by callback function; it's called for each line of the input.
 func OptionLiveSuffix(f func(doc *Document, row Row) (prefix string, usePrefix bool)) Option {
+	return func(p *Prompt) error {
+		p.renderer.suffixCallback = func(doc *Document, row Row) (StyledText, bool) {
+			suffix, ok := f(doc, row)
+			return plainText(suffix), ok
+		}
+		return nil
+	}
+}
+
+// OptionLiveStyledSuffix to change the suffix dynamically, like OptionLiveSuffix, with styled segments.
+func OptionLiveStyledSuffix(f func(doc *Document, row Row) (suffix StyledText, useSuffix bool)) Option {
 	return func(p *Prompt) error {
 		p.renderer.suffixCallback = f
 		return nil
@@ -102,8 +156,7 @@ func OptionCompletionWordSeparator(x string) Option {
 // OptionPrefixTextColor change a text color of prefix string
 func OptionPrefixTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.prefixText = x
+		p.renderer.changeStyle(RolePrefix, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -111,8 +164,7 @@ func OptionPrefixTextColor(x Color) Option {
 // OptionPrefixBackgroundColor to change a background color of prefix string
 func OptionPrefixBackgroundColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.prefixBG = x
+		p.renderer.changeStyle(RolePrefix, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -120,8 +172,7 @@ func OptionPrefixBackgroundColor(x Color) Option {
 // OptionInputTextColor to change a color of text which is input by user
 func OptionInputTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.inputText = x
+		p.renderer.changeStyle(RoleInput, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -129,8 +180,7 @@ func OptionInputTextColor(x Color) Option {
 // OptionInputBGColor to change a color of background which is input by user
 func OptionInputBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.inputBG = x
+		p.renderer.changeStyle(RoleInput, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -138,8 +188,7 @@ func OptionInputBGColor(x Color) Option {
 // OptionPreviewChoiceTextColor to change a text color which is completed
 func OptionPreviewChoiceTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.previewChoiceText = x
+		p.renderer.changeStyle(RolePreviewChoice, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -147,8 +196,7 @@ func OptionPreviewChoiceTextColor(x Color) Option {
 // OptionPreviewChoiceBGColor to change a background color which is completed
 func OptionPreviewChoiceBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.previewChoiceBG = x
+		p.renderer.changeStyle(RolePreviewChoice, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -156,8 +204,7 @@ func OptionPreviewChoiceBGColor(x Color) Option {
 // OptionChoiceTextColor to change a text color in drop down suggestions.
 func OptionChoiceTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.choiceText = x
+		p.renderer.changeStyle(RoleChoice, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -165,8 +212,7 @@ func OptionChoiceTextColor(x Color) Option {
 // OptionChoiceBGColor change a background color in drop down suggestions.
 func OptionChoiceBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.choiceBG = x
+		p.renderer.changeStyle(RoleChoice, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -174,8 +220,7 @@ func OptionChoiceBGColor(x Color) Option {
 // OptionSelectedChoiceTextColor to change a text color for completed text which is selected inside suggestions drop down box.
 func OptionSelectedChoiceTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.selectedChoiceText = x
+		p.renderer.changeStyle(RoleSelectedChoice, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -183,8 +228,7 @@ func OptionSelectedChoiceTextColor(x Color) Option {
 // OptionSelectedChoiceBGColor to change a background color for completed text which is selected inside suggestions drop down box.
 func OptionSelectedChoiceBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.selectedChoiceBG = x
+		p.renderer.changeStyle(RoleSelectedChoice, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -192,8 +236,7 @@ func OptionSelectedChoiceBGColor(x Color) Option {
 // OptionDescriptionTextColor to change a background color of description text in drop down suggestions.
 func OptionDescriptionTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.descriptionText = x
+		p.renderer.changeStyle(RoleDescription, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -201,8 +244,7 @@ func OptionDescriptionTextColor(x Color) Option {
 // OptionDescriptionBGColor to change a background color of description text in drop down suggestions.
 func OptionDescriptionBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.descriptionBG = x
+		p.renderer.changeStyle(RoleDescription, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -210,8 +252,7 @@ func OptionDescriptionBGColor(x Color) Option {
 // OptionSelectedDescriptionTextColor to change a text color of description which is selected inside suggestions drop down box.
 func OptionSelectedDescriptionTextColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.selectedDescriptionText = x
+		p.renderer.changeStyle(RoleSelectedDescription, func(s *Style) { s.Fg = x })
 		return nil
 	}
 }
@@ -219,8 +260,7 @@ func OptionSelectedDescriptionTextColor(x Color) Option {
 // OptionSelectedDescriptionBGColor to change a background color of description which is selected inside suggestions drop down box.
 func OptionSelectedDescriptionBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.selectedDescriptionBG = x
+		p.renderer.changeStyle(RoleSelectedDescription, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -228,8 +268,7 @@ func OptionSelectedDescriptionBGColor(x Color) Option {
 // OptionScrollbarThumbColor to change a thumb color on scrollbar.
 func OptionScrollbarThumbColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.scrollbarThumb = x
+		p.renderer.changeStyle(RoleScrollbarThumb, func(s *Style) { s.Bg = x })
 		return nil
 	}
 }
@@ -237,8 +276,40 @@ func OptionScrollbarThumbColor(x Color) Option {
 // OptionScrollbarBGColor to change a background color of scrollbar.
 func OptionScrollbarBGColor(x Color) Option {
 	return func(p *Prompt) error {
-		x, _ = p.renderer.ValidateColor(x)
-		p.renderer.Colors.scrollbarBG = x
+		p.renderer.changeStyle(RoleScrollbar, func(s *Style) { s.Bg = x })
+		return nil
+	}
+}
+
+// OptionErrorTextColor to change the color of errors returned by a ResultExecutor.
+func OptionErrorTextColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.changeStyle(RoleError, func(s *Style) { s.Fg = x })
+		return nil
+	}
+}
+
+// OptionToolbarTextColor to change the text color of the toolbar.
+func OptionToolbarTextColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.changeStyle(RoleToolbar, func(s *Style) { s.Fg = x })
+		return nil
+	}
+}
+
+// OptionToolbarBGColor to change the background color of the toolbar.
+func OptionToolbarBGColor(x Color) Option {
+	return func(p *Prompt) error {
+		p.renderer.changeStyle(RoleToolbar, func(s *Style) { s.Bg = x })
+		return nil
+	}
+}
+
+// OptionResultExecutor to use an executor which tells the prompt what to do next,
+// e.g. to exit or to not add the input to the history. It replaces the Executor given to New.
+func OptionResultExecutor(x ResultExecutor) Option {
+	return func(p *Prompt) error {
+		p.resultExecutor = x
 		return nil
 	}
 }
@@ -282,12 +353,32 @@ func OptionEditMode(m EditMode) Option {
 func OptionBindKey(b ...KeyBind) Option {
 	return func(p *Prompt) error {
 		for _, bind := range b {
-			p.keyBindings[bind.Key] = bind.Fn
+			p.keyBindings.Bind([]KeyCode{bind.Key}, bind.Fn)
 		}
 		return nil
 	}
 }
 
+// OptionBindKeySequence to bind key sequences (e.g. Ctrl+X Ctrl+E) to functions
+func OptionBindKeySequence(b ...SequenceBind) Option {
+	return func(p *Prompt) error {
+		for _, bind := range b {
+			p.keyBindings.Bind(bind.Sequence, bind.Fn)
+		}
+		return nil
+	}
+}
+
+// OptionKeySequenceTimeout to set how long to wait for the next key of a key sequence.
+// When it times out, the keys typed so far are discarded (or run, if bound by themselves).
+// Zero means wait forever.
+func OptionKeySequenceTimeout(x time.Duration) Option {
+	return func(p *Prompt) error {
+		p.keySequenceTimeout = x
+		return nil
+	}
+}
+
 // OptionBindControlSequence to make a binding to a specific control sequence.
 func OptionBindControlSequence(b ...ControlSequenceBind) Option {
 	return func(p *Prompt) error {
@@ -298,6 +389,184 @@ func OptionBindControlSequence(b ...ControlSequenceBind) Option {
 	}
 }
 
+// OptionInputrc to load key bindings and settings from a readline inputrc-like file (see inputrc.go).
+// Lines that can't be applied are skipped (see Prompt.InputrcWarnings); only a read error fails.
+func OptionInputrc(r io.Reader) Option {
+	return func(p *Prompt) error {
+		ip := &inputrcParser{p: p}
+		return ip.load(r, "inputrc")
+	}
+}
+
+// OptionInputrcFile to load key bindings and settings from an inputrc file, if it exists.
+// A leading "~/" in 'path' is replaced with the user's home directory.
+func OptionInputrcFile(path string) Option {
+	return func(p *Prompt) error {
+		ip := &inputrcParser{p: p}
+		if err := ip.loadFile(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+}
+
+// OptionInteractive to choose whether to use the terminal, or to just read lines from stdin.
+// By default (InteractiveAuto) lines are read when stdin or stdout isn't a terminal,
+// e.g. in a pipeline, so scripts can drive the executor.
+func OptionInteractive(x Interactivity) Option {
+	return func(p *Prompt) error {
+		p.interactivity = x
+		return nil
+	}
+}
+
+// OptionRecord to record the session (the input, the prompt's output and size changes)
+// to 'w' in the asciicast v2 format, e.g. to reproduce a bug with _tools/replay.
+// Output of the executor isn't recorded.
+func OptionRecord(w io.Writer) Option {
+	return func(p *Prompt) error {
+		p.recording = w
+		return nil
+	}
+}
+
+// OptionLineNumbers to show line numbers before the prefix of each line of the input.
+func OptionLineNumbers(x bool) Option {
+	return func(p *Prompt) error {
+		p.renderer.lineNumbers = x
+		return nil
+	}
+}
+
+// OptionScrollIndicators to show arrows at the right edge when an input taller than the window
+// has rows scrolled out of view above or below.
+func OptionScrollIndicators(x bool) Option {
+	return func(p *Prompt) error {
+		p.renderer.scrollIndicators = x
+		return nil
+	}
+}
+
+// OptionToolbar to show a toolbar below the input and the completion menu,
+// e.g. with the current mode or a key hint. It's updated whenever the prompt is rendered;
+// see OptionToolbarRefresh and Prompt.Invalidate to update it in between.
+func OptionToolbar(f Toolbar) Option {
+	return func(p *Prompt) error {
+		p.toolbar = f
+		p.renderer.toolbar = func(doc *Document) StyledText {
+			return f(doc, p.toolbarState())
+		}
+		return nil
+	}
+}
+
+// OptionToolbarRefresh to render the toolbar again at an interval (e.g. to show a clock).
+func OptionToolbarRefresh(x time.Duration) Option {
+	return func(p *Prompt) error {
+		p.toolbarRefresh = x
+		return nil
+	}
+}
+
+// OptionColorDepth to set the number of colors the terminal can display, instead of detecting it
+// (see DetectColorDepth); other colors are replaced with the nearest one it can display.
+func OptionColorDepth(x ColorDepth) Option {
+	return func(p *Prompt) error {
+		p.renderer.colorDepth = x
+		return nil
+	}
+}
+
+// OptionStyledUnderlines to set whether the terminal supports underline styles (e.g. curly) and colors.
+// They're only used with 24-bit colors (see OptionColorDepth); otherwise text is just underlined.
+func OptionStyledUnderlines(x bool) Option {
+	return func(p *Prompt) error {
+		p.renderer.styledUnderlines = x
+		return nil
+	}
+}
+
+// OptionTheme to set the styles of the parts of the prompt (see Theme); DarkTheme is the default.
+// Options changing colors (e.g. OptionPrefixTextColor) change the theme set before them.
+func OptionTheme(t Theme) Option {
+	return func(p *Prompt) error {
+		p.renderer.theme = t
+		return nil
+	}
+}
+
+// OptionThemeFile to load the theme from a file (see LoadTheme).
+func OptionThemeFile(path string) Option {
+	return func(p *Prompt) error {
+		t, err := LoadThemeFile(path)
+		if err != nil {
+			return err
+		}
+		p.renderer.theme = t
+		return nil
+	}
+}
+
+// OptionTransientPrompt to redraw an input once it's accepted or cancelled, e.g. with a shorter prefix,
+// so the scrollback stays compact while the live prompt can show more.
+func OptionTransientPrompt(f TransientPrompt) Option {
+	return func(p *Prompt) error {
+		p.renderer.transient = f
+		return nil
+	}
+}
+
+// OptionSynchronizedOutput to choose whether screen updates are wrapped in synchronized output sequences
+// (ESC[?2026h ... ESC[?2026l), so terminals supporting them don't show partial updates.
+// It's enabled by default, except on Windows.
+func OptionSynchronizedOutput(x bool) Option {
+	return func(p *Prompt) error {
+		p.renderer.syncOutput = x
+		return nil
+	}
+}
+
+// OptionKeyboardProtocol to ask the terminal to report modified keys unambiguously
+// (e.g. Ctrl+Shift combinations, or Ctrl+I apart from Tab).
+func OptionKeyboardProtocol(x KeyboardProtocol) Option {
+	return func(p *Prompt) error {
+		p.renderer.keyboardProtocol = x
+		return nil
+	}
+}
+
+// OptionMouse to enable mouse support; clicking in the input moves the cursor,
+// clicking a completion choice selects it (a second click accepts it)
+// and the scroll wheel scrolls the completion menu.
+func OptionMouse(enabled bool) Option {
+	return func(p *Prompt) error {
+		p.renderer.mouse = enabled
+		return nil
+	}
+}
+
+// OptionProbeTerminal to ask the terminal about itself when the prompt starts (see Prompt.TerminalInfo):
+// its version, its device attributes and where the cursor is, so the prompt starts at the beginning of the line
+// even if the previous output didn't end with a newline.
+func OptionProbeTerminal(enabled bool) Option {
+	return func(p *Prompt) error {
+		p.probe = enabled
+		return nil
+	}
+}
+
+// OptionKeepPartialLine to keep a partial line (output that didn't end with a newline, e.g. of the executor)
+// when the prompt starts, like zsh's PROMPT_SP: it's ended with 'marker' (which may be nil,
+// e.g. DefaultPartialLineMarker) and a newline, instead of being written over. It implies OptionProbeTerminal.
+func OptionKeepPartialLine(marker StyledText) Option {
+	return func(p *Prompt) error {
+		p.probe = true
+		p.renderer.keepPartialLine = true
+		p.renderer.partialLineMarker = marker
+		return nil
+	}
+}
+
 // OptionShowCompletionAtStart to set completion window is open at start.
 func OptionShowCompletionAtStart(enabled bool) Option {
 	return func(p *Prompt) error {
@@ -334,7 +603,15 @@ func New(executor Executor, completer Completer, opts ...Option) *Prompt {
 		history:     NewHistory(),
 		completion:  NewCompletionManager(completer, 6),
 		editMode:    EmacsMode, // All the above assume that bash is running in the default Emacs setting
-		keyBindings: make(map[KeyCode]KeyBindFunc, 10),
+		keyBindings: NewKeyMap(),
+
+		inputrcBindings: NewKeyMap(),
+
+		keySequenceTimeout:   defaultKeySequenceTimeout,
+		keySequenceTimeoutCh: make(chan int, 1),
+
+		invalidateCh: make(chan struct{}, 1),
+		readErrCh:    make(chan error, 1),
 	}
 
 	for _, opt := range opts {
@@ -342,5 +619,8 @@ func New(executor Executor, completer Completer, opts ...Option) *Prompt {
 			panic(err)
 		}
 	}
+	if pt.recording != nil {
+		pt.startRecording(pt.recording)
+	}
 	return pt
 }
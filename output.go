@@ -118,6 +118,34 @@ const (
 	White
 )
 
+// Style is the display style of text: colors and attributes.
+type Style struct {
+	Fg, Bg         Color
+	Bold           bool
+	Faint          bool
+	Italic         bool
+	Underline      bool
+	UnderlineStyle UnderlineStyle // if Underline is set; not widely supported
+	UnderlineColor Color          // if Underline is set; nil is the text color. Not widely supported.
+	Blink          bool
+	Reverse        bool
+	Conceal        bool
+	CrossedOut     bool
+
+	link string // the URL of an OSC 8 hyperlink in text (see Hyperlink); not set by SetStyle
+}
+
+// UnderlineStyle is the kind of line text is underlined with.
+type UnderlineStyle int
+
+const (
+	UnderlineSingle UnderlineStyle = iota
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
 // ConsoleWriter is an interface to abstract output layer.
 type ConsoleWriter interface {
 	/* Write */
@@ -166,6 +194,10 @@ type ConsoleWriter interface {
 	CursorBack(n int)
 	// AskForCPR asks for a cursor position report (CPR).
 	AskForCPR()
+	// AskForDeviceAttributes asks for the primary device attributes (DA1), which every terminal reports.
+	AskForDeviceAttributes()
+	// AskForVersion asks for the terminal's name and version (XTVERSION); not all terminals report it.
+	AskForVersion()
 	// SaveCursor saves current cursor position.
 	SaveCursor()
 	// RestoreCursor restores cursor position saved by the last SaveCursor.
@@ -185,8 +217,15 @@ type ConsoleWriter interface {
 	// ClearTitle clears a title of terminal window.
 	ClearTitle()
 
+	/* Hyperlink */
+
+	// SetHyperlink makes the text written next an OSC 8 hyperlink to 'url'; "" ends the link.
+	SetHyperlink(url string)
+
 	/* Font */
 
 	// SetColor sets text and background colors. and specify whether text is bold.
 	SetColor(fg, bg Color, bold bool)
+	// SetStyle sets the colors and attributes of the text written next; attributes 's' doesn't set are reset.
+	SetStyle(s Style)
 }
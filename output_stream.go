@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"io"
+)
+
+// StreamWriter is a ConsoleWriter writing VT100 escape sequences to an io.Writer,
+// e.g. a file, a pipe or a terminal emulator.
+type StreamWriter struct {
+	VT100Writer
+	out io.Writer
+}
+
+// Flush to flush buffer
+func (w *StreamWriter) Flush() error {
+	_, err := w.out.Write(w.buffer)
+	if err != nil {
+		return err
+	}
+	w.buffer = []byte{}
+	return nil
+}
+
+var _ ConsoleWriter = &StreamWriter{}
+
+// NewStreamWriter returns ConsoleWriter object to write to 'out'.
+func NewStreamWriter(out io.Writer) *StreamWriter {
+	return &StreamWriter{
+		out: out,
+	}
+}
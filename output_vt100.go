@@ -3,6 +3,7 @@ package prompt
 import (
 	"bytes"
 	"strconv"
+	"strings"
 )
 
 // VT100Writer generates VT100 escape sequences.
@@ -15,6 +16,11 @@ func (w *VT100Writer) WriteRaw(data []byte) {
 	w.buffer = append(w.buffer, data...)
 }
 
+// buffered returns the output that hasn't been flushed yet.
+func (w *VT100Writer) buffered() []byte {
+	return w.buffer
+}
+
 // Write to write safety byte array by removing control sequences.
 func (w *VT100Writer) Write(data []byte) {
 	w.WriteRaw(bytes.Replace(data, []byte{0x1b}, []byte{'?'}, -1))
@@ -145,6 +151,16 @@ func (w *VT100Writer) AskForCPR() {
 	w.WriteRaw([]byte{0x1b, '[', '6', 'n'})
 }
 
+// AskForDeviceAttributes asks for the primary device attributes (DA1), which every terminal reports.
+func (w *VT100Writer) AskForDeviceAttributes() {
+	w.WriteRaw([]byte{0x1b, '[', 'c'})
+}
+
+// AskForVersion asks for the terminal's name and version (XTVERSION); not all terminals report it.
+func (w *VT100Writer) AskForVersion() {
+	w.WriteRaw([]byte{0x1b, '[', '>', '0', 'q'})
+}
+
 // SaveCursor saves current cursor position.
 func (w *VT100Writer) SaveCursor() {
 	//fmt.Fprintln(os.Stderr, "\x1b[33;1mSCP\x1b[m")
@@ -201,6 +217,19 @@ func (w *VT100Writer) ClearTitle() {
 	w.WriteRawStr("\x1b]2;\x07")
 }
 
+/* Hyperlink */
+
+// SetHyperlink makes the text written next an OSC 8 hyperlink to 'url'; "" ends the link.
+func (w *VT100Writer) SetHyperlink(url string) {
+	url = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+	w.WriteRawStr(hyperlinkStart + ";" + url + "\x1b\\")
+}
+
 /* Font */
 
 // SetColor sets text and background colors. and specify whether text is bold.
@@ -214,6 +243,68 @@ func (w *VT100Writer) SetColor(fg, bg Color, bold bool) {
 	}
 }
 
+// SetStyle sets the colors and attributes of the text written next; attributes 's' doesn't set are reset.
+func (w *VT100Writer) SetStyle(s Style) {
+	params := []string{displayAttributeParameters[DisplayReset]}
+	for _, a := range []struct {
+		set  bool
+		attr DisplayAttribute
+	}{
+		{s.Bold, DisplayBold},
+		{s.Faint, DisplayLowIntensity},
+		{s.Italic, DisplayItalic},
+		{s.Blink, DisplayBlink},
+		{s.Reverse, DisplayReverse},
+		{s.Conceal, DisplayInvisible},
+		{s.CrossedOut, DisplayCrossedOut},
+	} {
+		if a.set {
+			params = append(params, displayAttributeParameters[a.attr])
+		}
+	}
+	if s.Underline {
+		params = append(params, underlineParameters[s.UnderlineStyle])
+		if s.UnderlineColor != nil {
+			params = append(params, underlineColorParameter(s.UnderlineColor))
+		}
+	}
+	if s.Fg == nil {
+		s.Fg = DefaultColor
+	}
+	if s.Bg == nil {
+		s.Bg = DefaultColor
+	}
+	params = append(params,
+		colorParameter(s.Fg, foregroundANSIColors, trueColorFG, color256FG),
+		colorParameter(s.Bg, backgroundANSIColors, trueColorBG, color256BG))
+	w.WriteRawStr(CSI + strings.Join(params, sep) + end)
+}
+
+// underlineParameters are the SGR parameters of the underline styles (with a colon sub-parameter, as in kitty).
+var underlineParameters = map[UnderlineStyle]string{
+	UnderlineSingle: "4",
+	UnderlineDouble: "4:2",
+	UnderlineCurly:  "4:3",
+	UnderlineDotted: "4:4",
+	UnderlineDashed: "4:5",
+}
+
+// underlineColorParameter returns the SGR parameters selecting 'c' as the underline color.
+// There's no short form for the ANSI colors; they're selected from the 256-color palette.
+func underlineColorParameter(c Color) string {
+	switch c := c.(type) {
+	case RGBColor:
+		return underlineTrueColor + c.Code
+	case Ansi256Color:
+		return underlineColor256 + strconv.Itoa(int(c))
+	case AnsiColor:
+		if c != DefaultColor {
+			return underlineColor256 + strconv.Itoa(int(c-Black))
+		}
+	}
+	return underlineDefaultColor
+}
+
 // SetDisplayAttributes to set VT100 display attributes.
 const (
 	CSI         = "\x1b["
@@ -221,8 +312,29 @@ const (
 	sep         = ";"
 	trueColorFG = "38;2;"
 	trueColorBG = "48;2;"
+	color256FG  = "38;5;"
+	color256BG  = "48;5;"
+
+	underlineTrueColor    = "58;2;"
+	underlineColor256     = "58;5;"
+	underlineDefaultColor = "59"
 )
 
+// colorParameter returns the SGR parameter(s) selecting 'c'; unknown colors are the default color.
+func colorParameter(c Color, ansi map[Color]string, trueColor, color256 string) string {
+	switch c := c.(type) {
+	case RGBColor:
+		return trueColor + c.Code
+	case Ansi256Color:
+		return color256 + strconv.Itoa(int(c))
+	case AnsiColor:
+		if v, ok := ansi[c]; ok {
+			return v
+		}
+	}
+	return ansi[DefaultColor]
+}
+
 func (w *VT100Writer) SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribute) {
 	w.WriteRawStr(CSI)
 
@@ -244,30 +356,9 @@ func (w *VT100Writer) SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribu
 		bg = DefaultColor
 	}
 
-	// foreground color
-	if tfg, ok := fg.(RGBColor); ok {
-		w.WriteRawStr(trueColorFG)
-		w.WriteRawStr(tfg.Code)
-	} else {
-		if v, ok = foregroundANSIColors[fg.(AnsiColor)]; !ok {
-			v = foregroundANSIColors[DefaultColor]
-		}
-		w.WriteRawStr(v)
-	}
-
+	w.WriteRawStr(colorParameter(fg, foregroundANSIColors, trueColorFG, color256FG))
 	w.WriteRawStr(sep)
-
-	// background color
-	if tbg, ok := bg.(RGBColor); ok {
-		w.WriteRawStr(trueColorBG)
-		w.WriteRawStr(tbg.Code)
-	} else {
-		if v, ok = backgroundANSIColors[bg.(AnsiColor)]; !ok {
-			v = backgroundANSIColors[DefaultColor]
-		}
-		w.WriteRawStr(v)
-	}
-
+	w.WriteRawStr(colorParameter(bg, backgroundANSIColors, trueColorBG, color256BG))
 	w.WriteRawStr(end)
 }
 
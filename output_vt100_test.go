@@ -79,3 +79,97 @@ func TestVT100WriterWriteRawStr(t *testing.T) {
 		}
 	}
 }
+
+func TestVT100WriterSetColor(t *testing.T) {
+	scenarioTable := []struct {
+		fg, bg   Color
+		bold     bool
+		expected string
+	}{
+		{fg: DefaultColor, bg: DefaultColor, expected: "\x1b[0;39;49m"},
+		{fg: Red, bg: nil, bold: true, expected: "\x1b[1;31;49m"},
+		{fg: Ansi256Color(196), bg: Ansi256Color(17), expected: "\x1b[0;38;5;196;48;5;17m"},
+		{fg: NewRGB(1, 2, 3), bg: White, expected: "\x1b[0;38;2;1;2;3;107m"},
+	}
+
+	for _, s := range scenarioTable {
+		pw := &VT100Writer{}
+		pw.SetColor(s.fg, s.bg, s.bold)
+
+		if string(pw.buffer) != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, string(pw.buffer))
+		}
+	}
+}
+
+func TestVT100WriterSetStyle(t *testing.T) {
+	scenarioTable := []struct {
+		style    Style
+		expected string
+	}{
+		{style: Style{}, expected: "\x1b[0;39;49m"},
+		{style: Style{Fg: Red, Bold: true, Italic: true}, expected: "\x1b[0;1;3;31;49m"},
+		{style: Style{Faint: true, Blink: true, Reverse: true, Conceal: true, CrossedOut: true}, expected: "\x1b[0;2;5;7;8;9;39;49m"},
+		{style: Style{Underline: true}, expected: "\x1b[0;4;39;49m"},
+		{style: Style{Underline: true, UnderlineStyle: UnderlineCurly, UnderlineColor: Red}, expected: "\x1b[0;4:3;58;5;1;39;49m"},
+		{style: Style{Underline: true, UnderlineStyle: UnderlineDashed, UnderlineColor: NewRGB(1, 2, 3)}, expected: "\x1b[0;4:5;58;2;1;2;3;39;49m"},
+		{style: Style{Underline: true, UnderlineColor: DefaultColor}, expected: "\x1b[0;4;59;39;49m"},
+		{style: Style{UnderlineStyle: UnderlineDouble, UnderlineColor: Red}, expected: "\x1b[0;39;49m"},
+	}
+
+	for _, s := range scenarioTable {
+		pw := &VT100Writer{}
+		pw.SetStyle(s.style)
+
+		if string(pw.buffer) != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, string(pw.buffer))
+		}
+	}
+}
+
+func TestRenderSetStyleUnderline(t *testing.T) {
+	curly := Style{Underline: true, UnderlineStyle: UnderlineCurly, UnderlineColor: NewRGB(255, 0, 0)}
+	scenarioTable := []struct {
+		depth    ColorDepth
+		styled   bool
+		expected string
+	}{
+		{depth: ColorDepthTrueColor, styled: true, expected: "\x1b[0;4:3;58;2;255;0;0;39;49m"},
+		{depth: ColorDepthTrueColor, styled: false, expected: "\x1b[0;4;39;49m"},
+		{depth: ColorDepth256, styled: true, expected: "\x1b[0;4;39;49m"},
+		{depth: ColorDepth16, styled: true, expected: "\x1b[0;4;39;49m"},
+		{depth: ColorDepthNone, styled: true, expected: "\x1b[0;4;39;49m"},
+	}
+
+	for _, s := range scenarioTable {
+		pw := &discardWriter{}
+		r := NewRender("> ", pw)
+		r.colorDepth = s.depth
+		r.styledUnderlines = s.styled
+		r.setStyle(curly)
+
+		if string(pw.buffer) != s.expected {
+			t.Errorf("%v (styled underlines: %v): Should be %#v, but got %#v", s.depth, s.styled, s.expected, string(pw.buffer))
+		}
+	}
+}
+
+func TestVT100WriterSetHyperlink(t *testing.T) {
+	scenarioTable := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://example.com/a?b=c", expected: "\x1b]8;;https://example.com/a?b=c\x1b\\"},
+		{url: "", expected: "\x1b]8;;\x1b\\"},
+		{url: "https://example.com/\x1b\\\x07x", expected: "\x1b]8;;https://example.com/\\x\x1b\\"},
+	}
+
+	for _, s := range scenarioTable {
+		pw := &VT100Writer{}
+		pw.SetHyperlink(s.url)
+
+		if string(pw.buffer) != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, string(pw.buffer))
+		}
+	}
+}
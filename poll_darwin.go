@@ -0,0 +1,42 @@
+// +build darwin
+
+package prompt
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// waitForInput blocks until 'fd' is readable (ready is true) or 'cancelFd' is (ready is false).
+// poll(2) doesn't support terminals on macOS, so this uses select(2).
+func waitForInput(fd, cancelFd int) (ready bool, err error) {
+	for {
+		var set unix.FdSet
+		fdSet(&set, fd)
+		fdSet(&set, cancelFd)
+		nfd := fd
+		if cancelFd > nfd {
+			nfd = cancelFd
+		}
+		err := unix.Select(nfd+1, &set, nil, nil, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if fdIsSet(&set, cancelFd) {
+			return false, nil
+		}
+		if fdIsSet(&set, fd) {
+			return true, nil
+		}
+	}
+}
+
+func fdSet(set *unix.FdSet, fd int) {
+	set.Bits[fd/32] |= 1 << (uint(fd) % 32)
+}
+
+func fdIsSet(set *unix.FdSet, fd int) bool {
+	return set.Bits[fd/32]&(1<<(uint(fd)%32)) != 0
+}
@@ -0,0 +1,31 @@
+// +build !windows,!darwin
+
+package prompt
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// waitForInput blocks until 'fd' is readable (ready is true) or 'cancelFd' is (ready is false).
+func waitForInput(fd, cancelFd int) (ready bool, err error) {
+	fds := []unix.PollFd{
+		{Fd: int32(cancelFd), Events: unix.POLLIN},
+		{Fd: int32(fd), Events: unix.POLLIN},
+	}
+	for {
+		_, err := unix.Poll(fds, -1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if fds[0].Revents != 0 {
+			return false, nil
+		}
+		if fds[1].Revents != 0 {
+			// also on POLLHUP/POLLERR; the following read reports it
+			return true, nil
+		}
+	}
+}
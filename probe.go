@@ -0,0 +1,183 @@
+package prompt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tatsujin/go-prompt/internal/debug"
+)
+
+// probeTimeout is how long the prompt waits for the terminal to answer the probe before it's rendered anyway.
+const probeTimeout = 200 * time.Millisecond
+
+// TerminalInfo is what the terminal reported when it was probed (see OptionProbeTerminal).
+type TerminalInfo struct {
+	// Cursor is where the cursor was (0-based) before the prompt was rendered; CursorKnown is false if it wasn't reported.
+	Cursor      Coord
+	CursorKnown bool
+	// Responded is true if the terminal reported its primary device attributes (DA1);
+	// Attributes are those after the conformance level, e.g. DeviceAttributeSixel.
+	Responded  bool
+	Attributes []int
+	// Version is the terminal's name and version (XTVERSION), e.g. "XTerm(367)"; "" if it wasn't reported.
+	Version string
+}
+
+// DefaultPartialLineMarker is the marker zsh shows at the end of a partial line (see OptionKeepPartialLine).
+var DefaultPartialLineMarker = StyledText{{Text: "%"}}
+
+// Some primary device attributes.
+const (
+	DeviceAttributeSixel     = 4
+	DeviceAttributeANSIColor = 22
+)
+
+// HasAttribute returns whether the terminal reported the primary device attribute 'a'.
+func (t TerminalInfo) HasAttribute(a int) bool {
+	for _, attr := range t.Attributes {
+		if attr == a {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDeviceAttributes decodes a primary device attributes report, "CSI ? level ; attributes c".
+func parseDeviceAttributes(cs ControlSequence) (attrs []int, ok bool) {
+	s := string(cs)
+	if !strings.HasPrefix(s, "\x1b[?") {
+		return nil, false
+	}
+	params, final, ok := parseCSI(ControlSequence("\x1b[" + s[3:]))
+	if !ok || final != 'c' || len(params) == 0 {
+		return nil, false
+	}
+	return params[1:], true
+}
+
+// parseVersionReport decodes an XTVERSION report, "DCS > | text ST".
+func parseVersionReport(cs ControlSequence) (version string, ok bool) {
+	s := string(cs)
+	if !strings.HasPrefix(s, "\x1bP>|") {
+		return "", false
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s[4:], "\x1b\\"), "\x07")
+	return s, true
+}
+
+// probeTerminal asks the terminal where the cursor is, its version and its device attributes,
+// if OptionProbeTerminal is set. The prompt isn't rendered until the terminal has answered (see endProbe),
+// or for probeTimeout.
+func (p *Prompt) probeTerminal() {
+	if !p.probe {
+		return
+	}
+	p.probing = true
+	p.terminalInfo = TerminalInfo{}
+	p.cprPending++
+	p.probeTimeout = time.After(probeTimeout)
+	p.renderer.probe()
+}
+
+// endProbe lets the prompt be rendered, from the start of the line the cursor was found on.
+func (p *Prompt) endProbe() {
+	if !p.probing {
+		return
+	}
+	p.probing = false
+	p.probeTimeout = nil
+	p.renderer.endProbe(p.terminalInfo)
+}
+
+// handleTerminalReport handles the terminal's answers to requests; they're never keys.
+func (p *Prompt) handleTerminalReport(cs ControlSequence) bool {
+	if p.cprPending > 0 {
+		// must check this before FindKey; e.g. "\x1b[1;2R" is also Shift+F3
+		if pos, ok := parseCPR(cs); ok {
+			p.cprPending--
+			p.handleCursorPositionReport(pos)
+			return true
+		}
+	} else if p.renderer.keyboardProtocol != KeyboardKitty {
+		// a late reply to a probe that timed out; only the kitty protocol sends
+		// modified F3 as "\x1b[1;<mod>R", otherwise it's dropped like the report
+		if _, ok := parseCPR(cs); ok {
+			return true
+		}
+	}
+	if version, ok := parseVersionReport(cs); ok {
+		p.terminalInfo.Version = version
+		return true
+	}
+	if attrs, ok := parseDeviceAttributes(cs); ok {
+		p.terminalInfo.Responded, p.terminalInfo.Attributes = true, attrs
+		if p.probing {
+			// the answers come in order, DA1 last; the cursor position won't be reported
+			if !p.terminalInfo.CursorKnown {
+				p.cprPending--
+			}
+			p.endProbe()
+		}
+		return true
+	}
+	return false
+}
+
+// TerminalInfo returns what the terminal reported when it was probed (see OptionProbeTerminal).
+func (p *Prompt) TerminalInfo() TerminalInfo {
+	return p.terminalInfo
+}
+
+// probe sends the requests of probeTerminal; nothing is rendered until endProbe.
+func (r *Render) probe() {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+
+	r.probing = true
+	r.out.AskForCPR()
+	r.out.AskForVersion()
+	r.out.AskForDeviceAttributes() // last, every terminal answers it
+	debug.AssertNoError(r.out.Flush())
+}
+
+// endProbe lets the prompt be rendered; if the cursor isn't at the start of a line
+// (e.g. the previous output didn't end with a newline), the prompt starts at the beginning of the line,
+// or of the next one with OptionKeepPartialLine.
+func (r *Render) endProbe(info TerminalInfo) {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+
+	r.probing = false
+	if !info.CursorKnown || info.Cursor.X == 0 || len(r.previous.rows) > 0 {
+		return
+	}
+	if r.keepPartialLine {
+		r.endPartialLine(info.Cursor.X)
+		return
+	}
+	r.previousCursor = Coord{info.Cursor.X, 0}
+	// what's on the line isn't known, it's all written over (or erased)
+	row := r.previous.row(0)
+	for x := range row {
+		row[x] = unknownCell
+	}
+}
+
+// endPartialLine ends the partial line the cursor is on (at column 'x') with the marker, if it fits, and a newline,
+// like zsh's PROMPT_SP.
+func (r *Render) endPartialLine(x Column) {
+	if x+Column(r.partialLineMarker.Width()) < r.termWidth {
+		var style Style
+		walkStyledText(Coord{}, r.termWidth, r.partialLineMarker, r.theme.Style(RolePartialLine).normalized(),
+			func(_ Coord, ch rune, _ Column, s Style) {
+				if s != style {
+					r.setStyle(s)
+					style = s
+				}
+				r.out.WriteRawStr(string(ch))
+			})
+		r.setStyle(defaultStyle)
+	}
+	r.out.WriteRawStr("\r\n")
+	debug.AssertNoError(r.out.Flush())
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDeviceAttributes(t *testing.T) {
+	scenarioTable := []struct {
+		input    ControlSequence
+		expected []int
+		ok       bool
+	}{
+		{input: "\x1b[?62;4;22c", expected: []int{4, 22}, ok: true},
+		{input: "\x1b[?1;2c", expected: []int{2}, ok: true},
+		{input: "\x1b[?6c", expected: []int{}, ok: true},
+		{input: "\x1b[62;22c"},
+		{input: "\x1b[?62;22R"},
+		{input: "c"},
+	}
+
+	for _, s := range scenarioTable {
+		actual, ok := parseDeviceAttributes(s.input)
+		if ok != s.ok || !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%q: Should be %v %v, but got %v %v", s.input, s.expected, s.ok, actual, ok)
+		}
+	}
+}
+
+func TestParseVersionReport(t *testing.T) {
+	scenarioTable := []struct {
+		input    ControlSequence
+		expected string
+		ok       bool
+	}{
+		{input: "\x1bP>|XTerm(367)\x1b\\", expected: "XTerm(367)", ok: true},
+		{input: "\x1bP>|kitty(0.26.5)\x07", expected: "kitty(0.26.5)", ok: true},
+		{input: "\x1bP"},
+		{input: "\x1bP1$r0m\x1b\\"},
+	}
+
+	for _, s := range scenarioTable {
+		actual, ok := parseVersionReport(s.input)
+		if ok != s.ok || actual != s.expected {
+			t.Errorf("%q: Should be %#v %v, but got %#v %v", s.input, s.expected, s.ok, actual, ok)
+		}
+	}
+}
+
+func TestTerminalInfoHasAttribute(t *testing.T) {
+	info := TerminalInfo{Responded: true, Attributes: []int{DeviceAttributeSixel, DeviceAttributeANSIColor}}
+	if !info.HasAttribute(DeviceAttributeSixel) {
+		t.Errorf("Should be true, but got false")
+	}
+	if info.HasAttribute(28) {
+		t.Errorf("Should be false, but got true")
+	}
+}
@@ -1,7 +1,10 @@
 package prompt
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	rdebug "runtime/debug"
 	"time"
@@ -9,9 +12,32 @@ import (
 	"github.com/tatsujin/go-prompt/internal/debug"
 )
 
+var (
+	// ErrEOF is returned when the input is ended, i.e. with Ctrl+D on an empty line.
+	ErrEOF = errors.New("prompt: end of input")
+	// ErrInterrupted is returned when the prompt is stopped by a signal (e.g. SIGINT or SIGTERM).
+	ErrInterrupted = errors.New("prompt: interrupted")
+	// ErrNoTerminal is returned (wrapped) when there's no terminal to read from.
+	ErrNoTerminal = errors.New("prompt: no terminal")
+)
+
 // Executor is called when user input something text.
 type Executor func(string)
 
+// ExecResult tells the prompt what to do after a ResultExecutor has run.
+type ExecResult struct {
+	// Exit stops the prompt; Run returns ExitCode.
+	Exit     bool
+	ExitCode int
+	// NoHistory keeps the input out of the history.
+	NoHistory bool
+	// Err, if not nil, is shown below the input.
+	Err error
+}
+
+// ResultExecutor is an Executor which returns what to do next (see OptionResultExecutor).
+type ResultExecutor func(string) ExecResult
+
 // Completer should return the suggest item from Document.
 type Completer func(Document) []Choice
 
@@ -21,11 +47,44 @@ type Prompt struct {
 	buf                     *Buffer
 	renderer                *Render
 	executor                Executor
+	resultExecutor          ResultExecutor
 	history                 *History
 	completion              *CompletionManager
-	keyBindings             map[KeyCode]KeyBindFunc
+	keyBindings             *KeyMap
+	inputrcBindings         *KeyMap  // loaded from an inputrc file (see OptionInputrc)
+	inputrcWarnings         []string // the inputrc lines that were skipped
 	ControlSequenceBindings map[ControlSequence]KeyBindFunc
 	editMode                EditMode
+
+	pendingClicks []MouseEvent // waiting for a cursor position report
+	cprPending    int          // number of requested cursor position reports
+
+	probe        bool             // see OptionProbeTerminal
+	probing      bool             // waiting for the terminal to answer the probe
+	probeTimeout <-chan time.Time // see probeTerminal
+	terminalInfo TerminalInfo
+
+	pendingKeys          []KeyCode // a partially typed key sequence
+	keySequenceTimeout   time.Duration
+	keySequenceTimer     *time.Timer
+	keySequenceGen       int // identifies the current pending key sequence
+	keySequenceTimeoutCh chan int
+
+	stopReadBufCh chan struct{} // see startReadBuffer
+	readBufDoneCh chan struct{}
+	readErrCh     chan error // the error that ended reading input (see readBuffer)
+
+	externalEdit bool // edit the buffer in an external editor (see editInEditor)
+
+	interactivity Interactivity
+	customIO      bool        // a custom ConsoleParser or ConsoleWriter is used
+	lines         *lineReader // input in non-interactive mode (see lineInput)
+
+	recording io.Writer // see OptionRecord
+
+	toolbar        Toolbar
+	toolbarRefresh time.Duration // see OptionToolbarRefresh
+	invalidateCh   chan struct{} // see Invalidate
 }
 
 // Exec is the struct contains user input context.
@@ -34,9 +93,29 @@ type Exec struct {
 }
 
 // Run starts prompt.
+// It returns when input ends (Ctrl+D on an empty line; exit code 0) or on a signal.
 func (p *Prompt) Run() (exitCode int) {
+	exitCode, err := p.RunContext(context.Background())
+	if err != nil && err != ErrEOF && err != ErrInterrupted {
+		debug.AssertNoError(err)
+	}
+	return exitCode
+}
+
+// RunContext starts prompt, like Run, but also returns when 'ctx' is done (with ctx.Err()).
+// Ending the input (Ctrl+D, or the input stream closing) returns ErrEOF, a signal returns ErrInterrupted
+// (and the exit code for it), and an error reading the input is returned wrapped.
+// If there's no terminal to read from, the error wraps ErrNoTerminal.
+func (p *Prompt) RunContext(ctx context.Context) (exitCode int, err error) {
+	if !p.isInteractive() {
+		return p.runLines(ctx)
+	}
+
 	debug.Log("start prompt")
-	p.setUp()
+	if err := p.setUp(); err != nil {
+		debug.Teardown()
+		return 1, err
+	}
 
 	if p.completion.showAtStart && p.completion.asYouType {
 		p.completion.FindCompletions(*p.buf.Document())
@@ -44,85 +123,195 @@ func (p *Prompt) Run() (exitCode int) {
 	p.renderer.Render(p.buf, p.completion)
 
 	bufCh := make(chan ControlSequence, 128)
-	stopReadBufCh := make(chan struct{}, 1) // buffered so the deferred tear down doesn't block
-	go p.readBuffer(bufCh, stopReadBufCh)
+	p.startReadBuffer(bufCh)
+	winSizeChangedCh := p.winSizeChanged()
 
 	exitCh := make(chan int)
 	termSizeCh := make(chan *WinSize)
 	stopHandleSignalCh := make(chan struct{}, 1) // buffered so the deferred tear down doesn't block
 	go p.handleSignals(exitCh, termSizeCh, stopHandleSignalCh)
 
+	refresh, stopRefresh := p.startRefresh()
+	defer stopRefresh()
+
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Fprintln(os.Stderr, r)
 			rdebug.PrintStack()
 		}
 
-		stopReadBufCh <- struct{}{}
+		p.stopReadBuffer()
 		stopHandleSignalCh <- struct{}{}
-		p.tearDown()
+		if terr := p.tearDown(); err == nil {
+			err = terr
+		}
 		debug.Teardown()
 	}()
 
 	for {
+		var shouldExit bool
+		var exec *Exec
+
 		select {
 		case cs := <-bufCh:
-			if shouldExit, exec := p.feed(cs); shouldExit {
-				fmt.Fprintln(os.Stderr, "EXIT")
-				p.renderer.BreakLine(p.buf, true)
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-				return
-			} else if exec != nil {
-				fmt.Fprintln(os.Stderr, "EXECUTE")
-
-				// execute entered command-line
-
-				// Stop goroutine to run readBuffer function
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-
-				// Unset raw mode
-				// Reset to Blocking mode because returned EAGAIN when still set non-blocking mode.
-				debug.AssertNoError(p.in.TearDown())
-				p.executor(exec.input)
+			p.applyWinSizeChange(winSizeChangedCh)
+			shouldExit, exec = p.feed(cs)
+		case gen := <-p.keySequenceTimeoutCh:
+			shouldExit, exec = p.keySequenceTimedOut(gen)
+		case w := <-termSizeCh:
+			p.renderer.UpdateWinSize(w)
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-winSizeChangedCh:
+			p.renderer.UpdateWinSize(p.in.GetWinSize())
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-refresh:
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-p.invalidateCh:
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-p.probeTimeout:
+			p.endProbe()
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case err := <-p.readErrCh:
+			if len(bufCh) > 0 || len(winSizeChangedCh) > 0 {
+				// handle the input (and size change) read before the error first
+				p.readErrCh <- err
+				continue
+			}
+			p.renderer.BreakLine(p.buf, true)
+			if err = readError(err); err == ErrEOF {
+				return 0, err
+			}
+			return 1, err
+		case code := <-exitCh:
+			p.renderer.BreakLine(p.buf, true)
+			return code, ErrInterrupted
+		case <-ctx.Done():
+			p.renderer.BreakLine(p.buf, true)
+			return 0, ctx.Err()
+		}
 
-				if p.completion.showAtStart && p.completion.asYouType {
-					p.completion.FindCompletions(*p.buf.Document())
+		if shouldExit {
+			fmt.Fprintln(os.Stderr, "EXIT")
+			p.renderer.BreakLine(p.buf, true)
+			return 0, ErrEOF
+		} else if exec != nil {
+			fmt.Fprintln(os.Stderr, "EXECUTE")
+
+			// execute entered command-line
+
+			// Stop goroutine to run readBuffer function
+			p.stopReadBuffer()
+			stopHandleSignalCh <- struct{}{}
+
+			var res ExecResult
+			err := p.runSuspended(func() {
+				res = p.execute(exec.input)
+				if res.Exit {
+					return
 				}
-				p.renderer.Render(p.buf, p.completion)
 
-				// Set raw mode
-				debug.AssertNoError(p.in.Setup())
-				go p.readBuffer(bufCh, stopReadBufCh)
-				go p.handleSignals(exitCh, termSizeCh, stopHandleSignalCh)
-			} else {
-				if p.completion.asYouType {
+				if p.completion.showAtStart && p.completion.asYouType {
 					p.completion.FindCompletions(*p.buf.Document())
 				}
-				p.renderer.Render(p.buf, p.completion)
+			})
+			if err != nil {
+				return 1, err
+			}
+			if res.Exit {
+				return res.ExitCode, nil
+			}
+			// the executor's output may not have ended with a newline
+			p.probeTerminal()
+			p.renderer.Render(p.buf, p.completion)
+
+			p.startReadBuffer(bufCh)
+			go p.handleSignals(exitCh, termSizeCh, stopHandleSignalCh)
+		} else if p.externalEdit {
+			p.externalEdit = false
+
+			p.stopReadBuffer()
+			stopHandleSignalCh <- struct{}{}
+
+			if err := p.runSuspended(p.editInEditor); err != nil {
+				return 1, err
+			}
+			p.renderer.Render(p.buf, p.completion)
+
+			p.startReadBuffer(bufCh)
+			go p.handleSignals(exitCh, termSizeCh, stopHandleSignalCh)
+		} else {
+			if p.completion.asYouType {
+				p.completion.FindCompletions(*p.buf.Document())
 			}
-		case w := <-termSizeCh:
-			p.renderer.UpdateWinSize(w)
 			p.renderer.Render(p.buf, p.completion)
-		case code := <-exitCh:
-			p.renderer.BreakLine(p.buf, true)
-			p.tearDown()
-			return code
-		default:
-			time.Sleep(10 * time.Millisecond)
 		}
 	}
-
-	return 0
 }
 
 func (p *Prompt) feed(cs ControlSequence) (shouldExit bool, exec *Exec) {
+	if p.handleTerminalReport(cs) {
+		return
+	}
+	if ev, ok := parseMouseEvent(cs); ok {
+		p.buf.toggleIndex = -1
+		p.handleMouse(ev)
+		return
+	}
+
 	key := FindKey(cs)
 
 	fmt.Fprintf(os.Stderr, "--> key: %v\n", []byte(cs))
 
+	// a plain character is a key too if it's bound, or in the middle of a key sequence (e.g. "C-x e")
+	if key == Undefined {
+		if r := []rune(string(cs)); len(r) == 1 {
+			k := keyFromRune(r[0])
+			if fn, isPrefix := p.lookupKeySequence([]KeyCode{k}); len(p.pendingKeys) > 0 || fn != nil || isPrefix {
+				key = k
+			}
+		}
+	}
+	if len(p.pendingKeys) > 0 && key == Undefined {
+		// not a key (e.g. a paste); resolve the pending keys as if they timed out
+		if shouldExit, exec = p.flushPendingKeys(); shouldExit || exec != nil {
+			return
+		}
+	}
+
 	p.buf.flags.translatedKey = Undefined
+	seq := append(append([]KeyCode{}, p.pendingKeys...), key)
+	fn, isPrefix := p.lookupKeySequence(seq)
+	if isPrefix {
+		// wait for the rest of the sequence
+		p.setPendingKeys(seq)
+		return
+	}
+	p.setPendingKeys(nil)
+	if len(seq) > 1 {
+		// a complete sequence; if it's not bound it's discarded
+		if fn != nil {
+			p.runKeySequence(fn, seq)
+		}
+		key = Ignore
+	}
+
+	return p.feedKey(key, cs)
+}
+
+// feedKey processes a single key (which is not part of a key sequence).
+func (p *Prompt) feedKey(key KeyCode, cs ControlSequence) (shouldExit bool, exec *Exec) {
+	defer func() {
+		// any other key ends toggling with toggle_beginning_of_line
+		if !p.buf.flags.toggled {
+			p.buf.toggleIndex = -1
+		}
+		p.buf.flags.toggled = false
+	}()
 
 	// are we already selecting a completion suggestion?
 	completing := p.completion.Completing()
@@ -152,11 +341,6 @@ func (p *Prompt) feed(cs ControlSequence) (shouldExit bool, exec *Exec) {
 		exec = &Exec{input: p.buf.Text()}
 
 		p.buf = NewBuffer()
-
-		// TODO: should add to history after the command has been successfully parsed!
-		if len(exec.input) > 0 {
-			p.history.Add(exec.input)
-		}
 	case KeyControl | KeyC:
 		p.renderer.BreakLine(p.buf, true)
 		p.buf = NewBuffer()
@@ -185,7 +369,9 @@ func (p *Prompt) feed(cs ControlSequence) (shouldExit bool, exec *Exec) {
 		}
 	case Undefined:
 		if !p.handleControlSequenceBinding(cs) {
-			p.buf.InsertText(string(cs), false, true)
+			if text := keyText(cs); text != "" {
+				p.buf.InsertText(text, false, true)
+			}
 		}
 	}
 
@@ -214,39 +400,125 @@ func (p *Prompt) handleCompletionKeyBinding(key KeyCode, completing bool) KeyCod
 	case KeyBackTab: // previous choice, or start completing
 		p.completion.Previous()
 	default:
-		if s, ok := p.completion.Selected(); ok {
-			w := p.buf.Document().GetWordBeforeCursorUntilSeparator(p.completion.wordSeparator)
-			if w != "" {
-				p.buf.DeleteBeforeCursor(Offset(len([]rune(w))))
-			}
-			p.buf.InsertText(s.Text, false, true)
+		// if completion was accepted using Enter, that key shouldn't be handled when we return
+		if p.acceptCompletion() && key == KeyEnter {
+			key = Ignore
+		}
+	}
+	return key
+}
 
-			// if completion was accepted using Enter, that key shouldn't be handled when we return
-			if key == KeyEnter {
-				key = Ignore
-			}
+// acceptCompletion replaces the word being completed with the selected choice (if any)
+// and closes the completion menu. Returns whether a choice was inserted.
+func (p *Prompt) acceptCompletion() bool {
+	s, ok := p.completion.Selected()
+	if ok {
+		w := p.buf.Document().GetWordBeforeCursorUntilSeparator(p.completion.wordSeparator)
+		if w != "" {
+			p.buf.DeleteBeforeCursor(Offset(len([]rune(w))))
 		}
+		p.buf.InsertText(s.Text, false, true)
+	}
+
+	p.completion.Reset()
+	return ok
+}
 
-		p.completion.Reset()
+// lookupKeySequence returns the function bound to 'seq' (if any),
+// and whether 'seq' is the beginning of a longer bound sequence.
+func (p *Prompt) lookupKeySequence(seq []KeyCode) (fn KeyBindFunc, isPrefix bool) {
+	maps := []*KeyMap{p.keyBindings, p.inputrcBindings}
+	if p.editMode == EmacsMode {
+		maps = append(maps, emacsKeyMap)
 	}
-	return key
+	for _, m := range maps {
+		f, more := m.Lookup(seq)
+		if fn == nil {
+			fn = f
+		}
+		isPrefix = isPrefix || more
+	}
+	return fn, isPrefix
+}
+
+// setPendingKeys sets the partially typed key sequence (nil if none)
+// and (re)starts the timer that gives up waiting for the rest of it.
+func (p *Prompt) setPendingKeys(seq []KeyCode) {
+	if p.keySequenceTimer != nil {
+		p.keySequenceTimer.Stop()
+		p.keySequenceTimer = nil
+	}
+	p.pendingKeys = seq
+	p.keySequenceGen++
+
+	if len(seq) == 0 {
+		p.renderer.status = ""
+		return
+	}
+	p.renderer.status = keySequenceName(seq) + "-"
+
+	if p.keySequenceTimeout > 0 {
+		gen := p.keySequenceGen
+		p.keySequenceTimer = time.AfterFunc(p.keySequenceTimeout, func() {
+			select {
+			case p.keySequenceTimeoutCh <- gen:
+			default: // nobody's listening
+			}
+		})
+	}
+}
+
+// keySequenceTimedOut is called when the rest of a key sequence didn't arrive in time.
+// A single pending key is processed on its own, a longer sequence is run if it's bound (or discarded).
+func (p *Prompt) keySequenceTimedOut(gen int) (shouldExit bool, exec *Exec) {
+	if gen != p.keySequenceGen { // the sequence was already resolved
+		return
+	}
+	return p.flushPendingKeys()
+}
+
+// flushPendingKeys resolves an incomplete key sequence: a single key is
+// processed on its own, a longer one runs its binding (if it has one).
+func (p *Prompt) flushPendingKeys() (shouldExit bool, exec *Exec) {
+	seq := p.pendingKeys
+	p.setPendingKeys(nil)
+	p.buf.flags.translatedKey = Undefined
+
+	key := Ignore
+	if len(seq) == 1 {
+		key = seq[0]
+	} else if fn, _ := p.lookupKeySequence(seq); fn != nil {
+		p.runKeySequence(fn, seq)
+	}
+	return p.feedKey(key, "")
+}
+
+// runKeySequence runs the function bound to a key sequence.
+func (p *Prompt) runKeySequence(fn KeyBindFunc, seq []KeyCode) {
+	ev := NewKeyEvent(p.buf, seq[len(seq)-1])
+	fn(ev)
+	p.postEventHandling(ev)
 }
 
 func (p *Prompt) handleKeyBinding(key KeyCode) bool {
 	ev := NewKeyEvent(p.buf, key)
-	// TODO: expose an API for the handlers:
-	//   the handler can then do e.g.:
-	//   ev.CallFunction("delete-char-backwards", args...)
 
 	handled := false
 
 	// Custom key bindings
-	if fn, ok := p.keyBindings[key]; ok {
+	if fn, _ := p.keyBindings.Lookup([]KeyCode{key}); fn != nil {
 		//fmt.Fprintf(os.Stderr, "executing custom key bind\n")
 		fn(ev)
 		handled = true
 	}
 
+	// inputrc bindings replace the built-in ones
+	if fn, _ := p.inputrcBindings.Lookup([]KeyCode{key}); fn != nil {
+		fn(ev)
+		p.postEventHandling(ev)
+		return true
+	}
+
 	// "generic" key bindings
 	if fn, ok := commonKeyBindings[key]; ok {
 		///fmt.Fprintf(os.Stderr, "executing common key bind\n")
@@ -293,6 +565,14 @@ func (p *Prompt) postEventHandling(ev *Event) {
 		p.buf.setTranslatedKey(ev.translatedKey)
 		ev.translatedKey = Undefined
 	}
+	if ev.externalEdit {
+		p.externalEdit = true
+		ev.externalEdit = false
+	}
+	if ev.clearScreen {
+		p.renderer.ClearScreen()
+		ev.clearScreen = false
+	}
 	if ev.termTitle != nil {
 		p.renderer.out.SetTitle(*ev.termTitle)
 		ev.termTitle = nil
@@ -300,10 +580,33 @@ func (p *Prompt) postEventHandling(ev *Event) {
 }
 
 // Input just returns user input text.
+// It returns "" when the input is ended (Ctrl+D on an empty line) or on a signal.
 func (p *Prompt) Input() string {
+	text, err := p.InputContext(context.Background())
+	if err != nil && err != ErrEOF && err != ErrInterrupted {
+		debug.AssertNoError(err)
+	}
+	return text
+}
+
+// InputContext returns user input text, like Input, but also returns when 'ctx' is done (with ctx.Err()).
+// Ending the input (Ctrl+D, or the input stream closing) returns ErrEOF, a signal returns ErrInterrupted,
+// and an error reading the input is returned wrapped.
+// If there's no terminal to read from, the error wraps ErrNoTerminal.
+func (p *Prompt) InputContext(ctx context.Context) (text string, err error) {
+	if !p.isInteractive() {
+		text, err = p.lineInput().readLine(ctx)
+		if err == nil && len(text) > 0 {
+			p.history.Add(text)
+		}
+		return text, err
+	}
+
 	defer debug.Teardown()
 	debug.Log("start prompt")
-	p.setUp()
+	if err := p.setUp(); err != nil {
+		return "", err
+	}
 
 	if p.completion.showAtStart {
 		p.completion.FindCompletions(*p.buf.Document())
@@ -311,29 +614,89 @@ func (p *Prompt) Input() string {
 
 	p.renderer.Render(p.buf, p.completion)
 	bufCh := make(chan ControlSequence, 128)
-	stopReadBufCh := make(chan struct{})
-	go p.readBuffer(bufCh, stopReadBufCh)
+	p.startReadBuffer(bufCh)
+	winSizeChangedCh := p.winSizeChanged()
+
+	exitCh := make(chan int)
+	termSizeCh := make(chan *WinSize)
+	stopHandleSignalCh := make(chan struct{}, 1)
+	go p.handleSignals(exitCh, termSizeCh, stopHandleSignalCh)
+
+	refresh, stopRefresh := p.startRefresh()
+	defer stopRefresh()
 
 	defer func() {
-		stopReadBufCh <- struct{}{}
-		p.tearDown()
+		p.stopReadBuffer()
+		stopHandleSignalCh <- struct{}{}
+		if terr := p.tearDown(); err == nil {
+			err = terr
+		}
 	}()
 
 	for {
+		var shouldExit bool
+		var e *Exec
+
 		select {
 		case b := <-bufCh:
-			if shouldExit, e := p.feed(b); shouldExit {
-				p.renderer.BreakLine(p.buf, true)
-				return ""
-			} else if e != nil {
-				// Stop goroutine to run readBuffer function
-				return e.input
-			} else {
-				p.completion.FindCompletions(*p.buf.Document())
-				p.renderer.Render(p.buf, p.completion)
+			p.applyWinSizeChange(winSizeChangedCh)
+			shouldExit, e = p.feed(b)
+		case gen := <-p.keySequenceTimeoutCh:
+			shouldExit, e = p.keySequenceTimedOut(gen)
+		case w := <-termSizeCh:
+			p.renderer.UpdateWinSize(w)
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-winSizeChangedCh:
+			p.renderer.UpdateWinSize(p.in.GetWinSize())
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-refresh:
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-p.invalidateCh:
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case <-p.probeTimeout:
+			p.endProbe()
+			p.renderer.Render(p.buf, p.completion)
+			continue
+		case err := <-p.readErrCh:
+			if len(bufCh) > 0 || len(winSizeChangedCh) > 0 {
+				// handle the input (and size change) read before the error first
+				p.readErrCh <- err
+				continue
 			}
-		default:
-			time.Sleep(10 * time.Millisecond)
+			p.renderer.BreakLine(p.buf, true)
+			return "", readError(err)
+		case <-exitCh:
+			p.renderer.BreakLine(p.buf, true)
+			return "", ErrInterrupted
+		case <-ctx.Done():
+			p.renderer.BreakLine(p.buf, true)
+			return "", ctx.Err()
+		}
+
+		if shouldExit {
+			p.renderer.BreakLine(p.buf, true)
+			return "", ErrEOF
+		} else if e != nil {
+			if len(e.input) > 0 {
+				p.history.Add(e.input)
+			}
+			return e.input, nil
+		} else if p.externalEdit {
+			p.externalEdit = false
+
+			p.stopReadBuffer()
+			if err := p.runSuspended(p.editInEditor); err != nil {
+				return "", err
+			}
+			p.renderer.Render(p.buf, p.completion)
+			p.startReadBuffer(bufCh)
+		} else {
+			p.completion.FindCompletions(*p.buf.Document())
+			p.renderer.Render(p.buf, p.completion)
 		}
 	}
 }
@@ -342,29 +705,146 @@ func (p *Prompt) OutputAsync(format string, a ...interface{}) {
 	p.renderer.OutputAsync(p.buf, p.completion, format, a...)
 }
 
-func (p *Prompt) readBuffer(bufCh chan ControlSequence, stopCh chan struct{}) {
+// winSizeChanged returns the channel of a WinSizeNotifier parser, or nil.
+func (p *Prompt) winSizeChanged() <-chan struct{} {
+	if in, ok := p.in.(WinSizeNotifier); ok {
+		return in.WinSizeChanged()
+	}
+	return nil
+}
+
+// applyWinSizeChange updates the size if the parser has reported a change (on 'ch', see winSizeChanged),
+// so input read after the change is handled at the new size.
+func (p *Prompt) applyWinSizeChange(ch <-chan struct{}) {
+	select {
+	case <-ch:
+		p.renderer.UpdateWinSize(p.in.GetWinSize())
+	default:
+	}
+}
+
+// readError returns the error for input that ended with 'err' (see readBuffer).
+func readError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return ErrEOF
+	}
+	return fmt.Errorf("prompt: read input: %w", err)
+}
+
+// startReadBuffer starts reading input (into 'bufCh') in a goroutine, until stopReadBuffer is called.
+func (p *Prompt) startReadBuffer(bufCh chan ControlSequence) {
+	p.stopReadBufCh = make(chan struct{})
+	p.readBufDoneCh = make(chan struct{})
+	go p.readBuffer(bufCh, p.stopReadBufCh, p.readBufDoneCh)
+}
+
+// stopReadBuffer stops the goroutine started by startReadBuffer.
+// If the parser is a CancelableParser its Read is canceled, and this waits for the goroutine to return.
+func (p *Prompt) stopReadBuffer() {
+	if p.stopReadBufCh == nil {
+		return
+	}
+	close(p.stopReadBufCh)
+	p.stopReadBufCh = nil
+	if in, ok := p.in.(CancelableParser); ok {
+		debug.AssertNoError(in.Cancel())
+		<-p.readBufDoneCh
+	}
+}
+
+func (p *Prompt) readBuffer(bufCh chan ControlSequence, stopCh, doneCh chan struct{}) {
 	debug.Log("start reading buffer")
+	defer close(doneCh)
+
+	_, blocking := p.in.(CancelableParser)
 	for {
 		select {
 		case <-stopCh:
 			debug.Log("stop reading buffer")
 			return
 		default:
-			if b, err := p.in.Read(); err == nil && !(len(b) == 1 && b[0] == 0) {
-				bufCh <- ControlSequence(b)
+		}
+
+		b, err := p.in.Read()
+		if err != nil && err != ErrReadCanceled && (blocking || errors.Is(err, io.EOF)) {
+			// the input is gone; reading it again would just spin
+			debug.Log("stop reading buffer: " + err.Error())
+			select {
+			case p.readErrCh <- err:
+			case <-stopCh:
 			}
+			return
+		}
+		if err == nil && !(len(b) == 1 && b[0] == 0) {
+			for _, cs := range splitSequences(b) {
+				select {
+				case bufCh <- cs:
+				case <-stopCh:
+					debug.Log("stop reading buffer")
+					return
+				}
+			}
+		} else if !blocking {
+			// no input; poll again a bit later
+			time.Sleep(10 * time.Millisecond)
 		}
-		time.Sleep(10 * time.Millisecond)
 	}
 }
 
-func (p *Prompt) setUp() {
-	debug.AssertNoError(p.in.Setup())
+// execute runs the executor on 'input', adds it to the history
+// and shows the error (unless the executor says otherwise).
+func (p *Prompt) execute(input string) ExecResult {
+	var res ExecResult
+	if p.resultExecutor != nil {
+		res = p.resultExecutor(input)
+	} else {
+		p.executor(input)
+	}
+
+	if len(input) > 0 && !res.NoHistory {
+		p.history.Add(input)
+	}
+	if res.Err != nil {
+		if p.isInteractive() {
+			p.renderer.RenderError(res.Err)
+		} else {
+			fmt.Fprintln(os.Stderr, res.Err)
+		}
+	}
+	return res
+}
+
+// runSuspended runs 'fn' with the terminal in its normal (cooked) mode,
+// e.g. to let another program use it.
+func (p *Prompt) runSuspended(fn func()) error {
+	// Unset raw mode
+	p.renderer.setTerminalModes(false)
+	if err := p.in.TearDown(); err != nil {
+		return err
+	}
+
+	fn()
+
+	// Set raw mode
+	if err := p.in.Setup(); err != nil {
+		return err
+	}
+	p.renderer.setTerminalModes(true)
+	return nil
+}
+
+func (p *Prompt) setUp() error {
+	if err := p.in.Setup(); err != nil {
+		return err
+	}
 	p.renderer.Setup()
 	p.renderer.UpdateWinSize(p.in.GetWinSize())
+	p.probeTerminal()
+	return nil
 }
 
-func (p *Prompt) tearDown() {
-	debug.AssertNoError(p.in.TearDown())
+func (p *Prompt) tearDown() error {
+	err := p.in.TearDown()
 	p.renderer.TearDown()
+	return err
 }
@@ -0,0 +1,162 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testParser is a CancelableParser reading input from a channel.
+type testParser struct {
+	input    chan []byte
+	cancel   chan struct{}
+	setupErr error
+	readErr  error // returned once the input is read
+}
+
+func newTestParser() *testParser {
+	return &testParser{
+		input:  make(chan []byte, 16),
+		cancel: make(chan struct{}, 1),
+	}
+}
+
+func (t *testParser) Setup() error         { return t.setupErr }
+func (t *testParser) TearDown() error      { return nil }
+func (t *testParser) GetWinSize() *WinSize { return &WinSize{Row: 24, Col: 80} }
+
+func (t *testParser) Read() ([]byte, error) {
+	select {
+	case b := <-t.input:
+		return b, nil
+	default:
+	}
+	if t.readErr != nil {
+		return nil, t.readErr
+	}
+	select {
+	case b := <-t.input:
+		return b, nil
+	case <-t.cancel:
+		return nil, ErrReadCanceled
+	}
+}
+
+func (t *testParser) Cancel() error {
+	select {
+	case t.cancel <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// discardWriter is a ConsoleWriter throwing away its output.
+type discardWriter struct {
+	VT100Writer
+}
+
+func (w *discardWriter) Flush() error {
+	w.buffer = []byte{}
+	return nil
+}
+
+func newInputTestPrompt(in ConsoleParser) *Prompt {
+	return New(func(string) {}, func(Document) []Choice { return nil },
+		OptionParser(in),
+		OptionWriter(&discardWriter{}),
+	)
+}
+
+func TestInputContext(t *testing.T) {
+	scenarioTable := []struct {
+		input    []string
+		expected string
+		err      error
+	}{
+		{input: []string{"abc", "\r"}, expected: "abc", err: nil},
+		{input: []string{"abc", "\x01", "x", "\r"}, expected: "xabc", err: nil},
+		{input: []string{"\x04"}, expected: "", err: ErrEOF},
+	}
+
+	for _, s := range scenarioTable {
+		in := newTestParser()
+		for _, b := range s.input {
+			in.input <- []byte(b)
+		}
+		text, err := newInputTestPrompt(in).InputContext(context.Background())
+		if text != s.expected || err != s.err {
+			t.Errorf("Should be %#v (%v), but got %#v (%v)", s.expected, s.err, text, err)
+		}
+	}
+}
+
+func TestInputContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	in := newTestParser()
+	in.input <- []byte("abc")
+	text, err := newInputTestPrompt(in).InputContext(ctx)
+	if text != "" || err != context.DeadlineExceeded {
+		t.Errorf("Should be %v, but got %#v (%v)", context.DeadlineExceeded, text, err)
+	}
+}
+
+func TestRunContextNoTerminal(t *testing.T) {
+	in := newTestParser()
+	in.setupErr = ErrNoTerminal
+	_, err := newInputTestPrompt(in).RunContext(context.Background())
+	if !errors.Is(err, ErrNoTerminal) {
+		t.Errorf("Should be %v, but got %v", ErrNoTerminal, err)
+	}
+}
+
+func TestRunContextReadError(t *testing.T) {
+	readErr := errors.New("input/output error")
+	in := newTestParser()
+	in.input <- []byte("abc")
+	in.readErr = readErr
+	code, err := newInputTestPrompt(in).RunContext(context.Background())
+	if code != 1 || !errors.Is(err, readErr) {
+		t.Errorf("Should be %v (%v), but got %v (%v)", 1, readErr, code, err)
+	}
+}
+
+func TestRunContextResultExecutor(t *testing.T) {
+	var executed []string
+	executor := func(in string) ExecResult {
+		executed = append(executed, in)
+		switch in {
+		case "exit":
+			return ExecResult{Exit: true, ExitCode: 3}
+		case "fail":
+			return ExecResult{Err: errors.New("failed")}
+		}
+		return ExecResult{NoHistory: in == "secret"}
+	}
+
+	in := newTestParser()
+	for _, b := range []string{"one", "\r", "secret", "\r", "fail", "\r", "exit", "\r", "never", "\r"} {
+		in.input <- []byte(b)
+	}
+	p := newInputTestPrompt(in)
+	OptionResultExecutor(executor)(p)
+
+	code, err := p.RunContext(context.Background())
+	if code != 3 || err != nil {
+		t.Errorf("Should be %v (%v), but got %v (%v)", 3, nil, code, err)
+	}
+	if expected := []string{"one", "secret", "fail", "exit"}; !reflect.DeepEqual(executed, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, executed)
+	}
+
+	var history []string
+	for _, e := range p.history.history {
+		history = append(history, e.text)
+	}
+	if expected := []string{"one", "fail", "exit"}; !reflect.DeepEqual(history, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, history)
+	}
+}
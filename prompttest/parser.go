@@ -0,0 +1,116 @@
+package prompttest
+
+import (
+	"io"
+	"sync"
+
+	prompt "github.com/tatsujin/go-prompt"
+)
+
+// Parser is a prompt.CancelableParser whose input is scripted with Feed.
+type Parser struct {
+	mu       sync.Mutex
+	queue    [][]byte
+	avail    chan struct{} // signaled when input is queued
+	cancel   chan struct{} // signaled by Cancel
+	closed   bool          // see Close
+	col, row uint16
+}
+
+var _ prompt.CancelableParser = &Parser{}
+
+// NewParser returns a Parser for a terminal of the given size.
+func NewParser(cols, rows int) *Parser {
+	return &Parser{
+		avail:  make(chan struct{}, 1),
+		cancel: make(chan struct{}, 1),
+		col:    uint16(cols),
+		row:    uint16(rows),
+	}
+}
+
+// Feed queues input; each string is returned by a separate Read,
+// like separate key presses on a terminal.
+func (p *Parser) Feed(inputs ...string) {
+	p.mu.Lock()
+	for _, in := range inputs {
+		p.queue = append(p.queue, []byte(in))
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.avail <- struct{}{}:
+	default:
+	}
+}
+
+// Close ends the input: once the fed input has been read, Read returns io.EOF,
+// like a terminal that has been hung up.
+func (p *Parser) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	select {
+	case p.avail <- struct{}{}:
+	default:
+	}
+}
+
+// Setup does nothing.
+func (p *Parser) Setup() error {
+	return nil
+}
+
+// TearDown does nothing.
+func (p *Parser) TearDown() error {
+	return nil
+}
+
+// GetWinSize returns the size set by NewParser or SetWinSize.
+func (p *Parser) GetWinSize() *prompt.WinSize {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &prompt.WinSize{Row: p.row, Col: p.col}
+}
+
+// SetWinSize changes the size returned by GetWinSize.
+func (p *Parser) SetWinSize(cols, rows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.col, p.row = uint16(cols), uint16(rows)
+}
+
+// Read returns the next fed input, waiting for it if needed.
+// It returns io.EOF when all input has been read after Close.
+func (p *Parser) Read() ([]byte, error) {
+	for {
+		p.mu.Lock()
+		if len(p.queue) > 0 {
+			b := p.queue[0]
+			p.queue = p.queue[1:]
+			p.mu.Unlock()
+			return b, nil
+		}
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			return nil, io.EOF
+		}
+
+		select {
+		case <-p.avail:
+		case <-p.cancel:
+			return nil, prompt.ErrReadCanceled
+		}
+	}
+}
+
+// Cancel makes a blocked (or the next) Read return prompt.ErrReadCanceled.
+func (p *Parser) Cancel() error {
+	select {
+	case p.cancel <- struct{}{}:
+	default:
+	}
+	return nil
+}
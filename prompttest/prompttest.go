@@ -0,0 +1,98 @@
+// Package prompttest provides a headless terminal for testing prompts:
+// a scripted input (Parser) and an emulated screen (Screen) to assert what was displayed,
+// e.g. completion menus, line wrapping, the cursor position and colors.
+//
+//	term := prompttest.NewTerminal(80, 24)
+//	p := prompt.New(executor, completer, term.Options()...)
+//	go p.RunContext(ctx)
+//	term.Type("sel")
+//	term.Press(prompt.KeyTab)
+//	if !term.Screen.WaitForText("select", time.Second) {
+//		t.Errorf("no completion:\n%s", term.Screen.Snapshot())
+//	}
+package prompttest
+
+import (
+	"fmt"
+	"sort"
+
+	prompt "github.com/tatsujin/go-prompt"
+)
+
+// Terminal is a Parser connected to a Screen; the screen's replies (e.g. cursor position reports)
+// are fed back as input, like on a real terminal.
+type Terminal struct {
+	Parser *Parser
+	Screen *Screen
+}
+
+// NewTerminal returns a Terminal of the given size.
+func NewTerminal(cols, rows int) *Terminal {
+	t := &Terminal{
+		Parser: NewParser(cols, rows),
+		Screen: NewScreen(cols, rows),
+	}
+	t.Screen.OnResponse = func(s string) {
+		t.Parser.Feed(s)
+	}
+	return t
+}
+
+// Options returns the options making a prompt use the terminal (which displays 24-bit colors).
+func (t *Terminal) Options() []prompt.Option {
+	return []prompt.Option{
+		prompt.OptionParser(t.Parser),
+		prompt.OptionWriter(prompt.NewStreamWriter(t.Screen)),
+		prompt.OptionColorDepth(prompt.ColorDepthTrueColor),
+	}
+}
+
+// Type feeds text as input, in one read (like a paste).
+func (t *Terminal) Type(text string) {
+	t.Parser.Feed(text)
+}
+
+// Press feeds the keys as input, each in a separate read.
+// It panics if a key has no control sequence in prompt.KeySequences.
+func (t *Terminal) Press(keys ...prompt.KeyCode) {
+	for _, k := range keys {
+		seq, ok := keySequences()[k]
+		if !ok {
+			panic(fmt.Sprintf("prompttest: no control sequence for key %d", k))
+		}
+		t.Parser.Feed(seq)
+	}
+}
+
+// preferredSequences are used when a key has several control sequences,
+// and the shortest one isn't what a terminal would send.
+var preferredSequences = map[prompt.KeyCode]string{
+	prompt.KeyEnter: "\r",
+}
+
+// keySequences returns the control sequence of each key in prompt.KeySequences:
+// the preferred one, or else the shortest (and then the first in lexical order).
+func keySequences() map[prompt.KeyCode]string {
+	seqs := make([]string, 0, len(prompt.KeySequences))
+	for cs := range prompt.KeySequences {
+		seqs = append(seqs, string(cs))
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		if len(seqs[i]) != len(seqs[j]) {
+			return len(seqs[i]) < len(seqs[j])
+		}
+		return seqs[i] < seqs[j]
+	})
+
+	m := make(map[prompt.KeyCode]string, len(seqs))
+	for k, seq := range preferredSequences {
+		m[k] = seq
+	}
+	for _, seq := range seqs {
+		k := prompt.KeySequences[prompt.ControlSequence(seq)]
+		if _, ok := m[k]; !ok {
+			m[k] = seq
+		}
+	}
+	return m
+}
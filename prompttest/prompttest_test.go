@@ -0,0 +1,118 @@
+package prompttest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/prompttest"
+)
+
+func completer(d prompt.Document) []prompt.Choice {
+	choices := []prompt.Choice{
+		{Text: "select", Description: "Select rows"},
+		{Text: "set", Description: "Set a variable"},
+		{Text: "show", Description: "Show tables"},
+	}
+	return prompt.FilterHasPrefix(choices, d.GetWordBeforeCursor(), true)
+}
+
+// run runs a prompt on the terminal until the test ends.
+func run(t *testing.T, term *prompttest.Terminal, opts ...prompt.Option) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p := prompt.New(func(string) {}, completer, append(term.Options(), opts...)...)
+	go func() {
+		defer close(done)
+		p.RunContext(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestTerminalCompletion(t *testing.T) {
+	term := prompttest.NewTerminal(40, 10)
+	run(t, term)
+
+	term.Type("se")
+	if !term.Screen.WaitForText("Set a variable", time.Second) {
+		t.Fatalf("Should show the completions, but got:\n%s", term.Screen.Snapshot())
+	}
+	lines := term.Screen.Lines()
+	if !strings.HasPrefix(lines[0], "> se") {
+		t.Errorf("Should be %#v, but got %#v", "> se", lines[0])
+	}
+	if strings.Contains(term.Screen.Snapshot(), "Show tables") {
+		t.Errorf("Should not show %#v, but got:\n%s", "show", term.Screen.Snapshot())
+	}
+
+	term.Press(prompt.KeyTab)
+	if !term.Screen.WaitFor(time.Second, func(s *prompttest.Screen) bool {
+		return strings.HasPrefix(s.Line(0), "> select")
+	}) {
+		t.Errorf("Should be %#v, but got:\n%s", "> select", term.Screen.Snapshot())
+	}
+}
+
+func TestTerminalWrapping(t *testing.T) {
+	term := prompttest.NewTerminal(20, 10)
+	run(t, term)
+
+	term.Type("abcdefghijklmnopqrstuvwxyz")
+	if !term.Screen.WaitFor(time.Second, func(s *prompttest.Screen) bool {
+		return s.Line(0) == "> abcdefghijklmnopqr" && s.Line(1) == "stuvwxyz"
+	}) {
+		t.Fatalf("Should wrap, but got:\n%s", term.Screen.Snapshot())
+	}
+	if x, y := term.Screen.Cursor(); x != 8 || y != 1 {
+		t.Errorf("Should be (%d, %d), but got (%d, %d)", 8, 1, x, y)
+	}
+}
+
+func TestTerminalClose(t *testing.T) {
+	for _, input := range []func(p *prompt.Prompt) error{
+		func(p *prompt.Prompt) error {
+			_, err := p.RunContext(context.Background())
+			return err
+		},
+		func(p *prompt.Prompt) error {
+			_, err := p.InputContext(context.Background())
+			return err
+		},
+	} {
+		term := prompttest.NewTerminal(40, 10)
+		p := prompt.New(func(string) {}, completer, term.Options()...)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- input(p)
+		}()
+
+		term.Type("sel")
+		term.Parser.Close()
+		select {
+		case err := <-errCh:
+			if err != prompt.ErrEOF {
+				t.Errorf("Should be %v, but got %v", prompt.ErrEOF, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Should have returned, but got:\n%s", term.Screen.Snapshot())
+		}
+	}
+
+	// the input before the end is handled
+	for i := 0; i < 20; i++ {
+		term := prompttest.NewTerminal(40, 10)
+		p := prompt.New(func(string) {}, completer, term.Options()...)
+		for _, in := range []string{"a", "b", "c", "\r"} {
+			term.Type(in)
+		}
+		term.Parser.Close()
+		if text, err := p.InputContext(context.Background()); text != "abc" || err != nil {
+			t.Fatalf("Should be %#v (%v), but got %#v (%v)", "abc", nil, text, err)
+		}
+	}
+}
@@ -0,0 +1,636 @@
+package prompttest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	runewidth "github.com/mattn/go-runewidth"
+	prompt "github.com/tatsujin/go-prompt"
+)
+
+// Style is the display style of a Cell.
+type Style struct {
+	Fg, Bg         prompt.Color
+	Bold           bool
+	Faint          bool
+	Italic         bool
+	Underline      bool
+	UnderlineStyle prompt.UnderlineStyle
+	UnderlineColor prompt.Color // nil is the text color
+	Blink          bool
+	Reverse        bool
+	Conceal        bool
+	CrossedOut     bool
+}
+
+var defaultStyle = Style{Fg: prompt.DefaultColor, Bg: prompt.DefaultColor}
+
+// Cell is a single character cell on the Screen.
+// The cell to the right of a wide character has Rune 0.
+type Cell struct {
+	Rune  rune
+	Style Style
+	Link  string // the URL of the OSC 8 hyperlink the character is in, if any
+}
+
+var blankCell = Cell{Rune: ' ', Style: defaultStyle}
+
+// The Screen's answers to the version (XTVERSION) and primary device attributes (DA1) requests:
+// a VT220 with ANSI colors.
+const (
+	Version          = "prompttest"
+	DeviceAttributes = "\x1b[?62;22c"
+)
+
+// Screen is an in-memory VT100 terminal emulator; it's an io.Writer, to be used with prompt.NewStreamWriter.
+// It handles what the prompt outputs: text (with wide characters and automatic wrapping),
+// cursor movement, erasing, scrolling, SGR colors and OSC 8 hyperlinks.
+// A line feed also returns to the first column, like a terminal with the (default) ONLCR output mode.
+type Screen struct {
+	// OnResponse, if set, receives the terminal's replies to requests, e.g. cursor position reports.
+	OnResponse func(string)
+
+	mu            sync.Mutex
+	cols, rows    int
+	cells         [][]Cell
+	x, y          int
+	wrapPending   bool // the last column has been written; the next character goes on the next line
+	style         Style
+	link          string // the URL of the current hyperlink
+	savedX        int
+	savedY        int
+	cursorVisible bool
+	title         string
+	pending       []byte        // an incomplete escape sequence or UTF-8 encoding
+	changed       chan struct{} // closed (and replaced) on every Write
+}
+
+// NewScreen returns a blank Screen of the given size.
+func NewScreen(cols, rows int) *Screen {
+	s := &Screen{
+		cols:          cols,
+		rows:          rows,
+		style:         defaultStyle,
+		cursorVisible: true,
+		changed:       make(chan struct{}),
+	}
+	s.cells = make([][]Cell, rows)
+	for y := range s.cells {
+		s.cells[y] = blankLine(cols)
+	}
+	return s
+}
+
+func blankLine(cols int) []Cell {
+	line := make([]Cell, cols)
+	for x := range line {
+		line[x] = blankCell
+	}
+	return line
+}
+
+// Write processes the output of the prompt.
+func (s *Screen) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.pending, b...)
+	s.pending = nil
+	for len(data) > 0 {
+		n := s.process(data)
+		if n == 0 { // incomplete; wait for more
+			s.pending = append([]byte{}, data...)
+			break
+		}
+		data = data[n:]
+	}
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return len(b), nil
+}
+
+// process handles the character, control character or escape sequence at the start of 'b',
+// and returns how many bytes it consumed (0 if 'b' is incomplete).
+func (s *Screen) process(b []byte) int {
+	switch c := b[0]; c {
+	case 0x1b:
+		return s.escape(b)
+	case '\r':
+		s.x, s.wrapPending = 0, false
+	case '\n':
+		s.x, s.wrapPending = 0, false
+		s.lineFeed()
+	case '\b':
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.x = (s.x/8 + 1) * 8
+		if s.x >= s.cols {
+			s.x = s.cols - 1
+		}
+	default:
+		if c < 0x20 || c == 0x7f {
+			break // other control characters are ignored
+		}
+		if !utf8.FullRune(b) {
+			return 0
+		}
+		r, n := utf8.DecodeRune(b)
+		s.put(r)
+		return n
+	}
+	return 1
+}
+
+// put writes a printable character at the cursor position.
+func (s *Screen) put(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return
+	}
+	if s.wrapPending || s.x+w > s.cols {
+		s.x, s.wrapPending = 0, false
+		s.lineFeed()
+	}
+	s.cells[s.y][s.x] = Cell{Rune: r, Style: s.style, Link: s.link}
+	if w == 2 {
+		s.cells[s.y][s.x+1] = Cell{Rune: 0, Style: s.style, Link: s.link}
+	}
+	s.x += w
+	if s.x >= s.cols {
+		s.x = s.cols - 1
+		s.wrapPending = true
+	}
+}
+
+// lineFeed moves the cursor down a row, scrolling the screen up at the bottom.
+func (s *Screen) lineFeed() {
+	if s.y < s.rows-1 {
+		s.y++
+		return
+	}
+	copy(s.cells, s.cells[1:])
+	s.cells[s.rows-1] = blankLine(s.cols)
+}
+
+// reverseIndex moves the cursor up a row, scrolling the screen down at the top.
+func (s *Screen) reverseIndex() {
+	if s.y > 0 {
+		s.y--
+		return
+	}
+	copy(s.cells[1:], s.cells)
+	s.cells[0] = blankLine(s.cols)
+}
+
+// escape handles an escape sequence; see process.
+func (s *Screen) escape(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				s.csi(string(b[2:i]), b[i])
+				return i + 1
+			}
+		}
+		return 0
+	case ']': // OSC, terminated by BEL or ST
+		for i := 2; i < len(b); i++ {
+			if b[i] == 0x07 {
+				s.osc(string(b[2:i]))
+				return i + 1
+			}
+			if b[i] == 0x1b && i+1 < len(b) && b[i+1] == '\\' {
+				s.osc(string(b[2:i]))
+				return i + 2
+			}
+		}
+		return 0
+	case '7':
+		s.savedX, s.savedY = s.x, s.y
+	case '8':
+		s.x, s.y, s.wrapPending = s.savedX, s.savedY, false
+	case 'D':
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	}
+	return 2
+}
+
+func (s *Screen) osc(data string) {
+	parts := strings.SplitN(data, ";", 2)
+	if len(parts) == 2 && (parts[0] == "0" || parts[0] == "2") {
+		s.title = parts[1]
+	}
+	if parts = strings.SplitN(data, ";", 3); len(parts) == 3 && parts[0] == "8" { // hyperlink, "8;params;url"
+		s.link = parts[2]
+	}
+}
+
+// csi handles "ESC [ params final".
+func (s *Screen) csi(params string, final byte) {
+	if strings.HasPrefix(params, ">") {
+		if final == 'q' && s.OnResponse != nil { // XTVERSION
+			s.OnResponse("\x1bP>|" + Version + "\x1b\\")
+		}
+		return
+	}
+	private := strings.HasPrefix(params, "?")
+	if private {
+		params = params[1:]
+	}
+	var args []int
+	if params != "" {
+		for _, p := range strings.Split(params, ";") {
+			n, _ := strconv.Atoi(p)
+			args = append(args, n)
+		}
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	if private {
+		if (final == 'h' || final == 'l') && arg(0, 0) == 25 {
+			s.cursorVisible = final == 'h'
+		}
+		return
+	}
+
+	switch final {
+	case 'A':
+		s.moveTo(s.x, s.y-arg(0, 1))
+	case 'B':
+		s.moveTo(s.x, s.y+arg(0, 1))
+	case 'C':
+		s.moveTo(s.x+arg(0, 1), s.y)
+	case 'D':
+		s.moveTo(s.x-arg(0, 1), s.y)
+	case 'H', 'f':
+		s.moveTo(arg(1, 1)-1, arg(0, 1)-1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			s.eraseLine(s.y, s.x, s.cols)
+			for y := s.y + 1; y < s.rows; y++ {
+				s.cells[y] = blankLine(s.cols)
+			}
+		case 1:
+			for y := 0; y < s.y; y++ {
+				s.cells[y] = blankLine(s.cols)
+			}
+			s.eraseLine(s.y, 0, s.x+1)
+		default:
+			for y := range s.cells {
+				s.cells[y] = blankLine(s.cols)
+			}
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			s.eraseLine(s.y, s.x, s.cols)
+		case 1:
+			s.eraseLine(s.y, 0, s.x+1)
+		default:
+			s.eraseLine(s.y, 0, s.cols)
+		}
+	case 'm':
+		s.sgr(strings.Split(params, ";"))
+	case 's':
+		s.savedX, s.savedY = s.x, s.y
+	case 'u':
+		s.x, s.y, s.wrapPending = s.savedX, s.savedY, false
+	case 'n':
+		if arg(0, 0) == 6 && s.OnResponse != nil {
+			s.OnResponse(fmt.Sprintf("\x1b[%d;%dR", s.y+1, s.x+1))
+		}
+	case 'c':
+		if arg(0, 0) == 0 && s.OnResponse != nil {
+			s.OnResponse(DeviceAttributes)
+		}
+	}
+}
+
+// moveTo moves the cursor, keeping it on the screen.
+func (s *Screen) moveTo(x, y int) {
+	s.x, s.y, s.wrapPending = clamp(x, 0, s.cols-1), clamp(y, 0, s.rows-1), false
+}
+
+func clamp(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+func (s *Screen) eraseLine(y, from, to int) {
+	for x := from; x < to && x < s.cols; x++ {
+		s.cells[y][x] = blankCell
+	}
+}
+
+// sgr handles "Select Graphic Rendition", i.e. colors and attributes.
+func (s *Screen) sgr(params []string) {
+	args := make([]int, len(params))
+	for i, p := range params {
+		args[i], _ = strconv.Atoi(p)
+	}
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(params[i], "4:") { // an underline style, e.g. "4:3" for curly
+			v, _ := strconv.Atoi(params[i][2:])
+			s.style.Underline = v != 0
+			if v > 0 {
+				s.style.UnderlineStyle = prompt.UnderlineStyle(v - 1)
+			}
+			continue
+		}
+		switch n := args[i]; {
+		case n == 0:
+			s.style = defaultStyle
+		case n == 1:
+			s.style.Bold = true
+		case n == 2:
+			s.style.Faint = true
+		case n == 22:
+			s.style.Bold, s.style.Faint = false, false
+		case n == 3:
+			s.style.Italic = true
+		case n == 23:
+			s.style.Italic = false
+		case n == 4:
+			s.style.Underline, s.style.UnderlineStyle = true, prompt.UnderlineSingle
+		case n == 21:
+			s.style.Underline, s.style.UnderlineStyle = true, prompt.UnderlineDouble
+		case n == 24:
+			s.style.Underline, s.style.UnderlineStyle = false, prompt.UnderlineSingle
+		case n == 5:
+			s.style.Blink = true
+		case n == 25:
+			s.style.Blink = false
+		case n == 7:
+			s.style.Reverse = true
+		case n == 27:
+			s.style.Reverse = false
+		case n == 8:
+			s.style.Conceal = true
+		case n == 28:
+			s.style.Conceal = false
+		case n == 9:
+			s.style.CrossedOut = true
+		case n == 29:
+			s.style.CrossedOut = false
+		case n >= 30 && n <= 37:
+			s.style.Fg = prompt.Black + prompt.AnsiColor(n-30)
+		case n >= 90 && n <= 97:
+			s.style.Fg = prompt.BrightBlack + prompt.AnsiColor(n-90)
+		case n == 39:
+			s.style.Fg = prompt.DefaultColor
+		case n >= 40 && n <= 47:
+			s.style.Bg = prompt.Black + prompt.AnsiColor(n-40)
+		case n >= 100 && n <= 107:
+			s.style.Bg = prompt.BrightBlack + prompt.AnsiColor(n-100)
+		case n == 49:
+			s.style.Bg = prompt.DefaultColor
+		case n == 59:
+			s.style.UnderlineColor = nil
+		case n == 38 || n == 48 || n == 58:
+			var c prompt.Color
+			if i+4 < len(args) && args[i+1] == 2 {
+				c = prompt.NewRGB(uint8(args[i+2]), uint8(args[i+3]), uint8(args[i+4]))
+				i += 4
+			} else if i+2 < len(args) && args[i+1] == 5 {
+				c = prompt.Ansi256Color(args[i+2])
+				i += 2
+			} else {
+				return
+			}
+			switch n {
+			case 38:
+				s.style.Fg = c
+			case 48:
+				s.style.Bg = c
+			default:
+				s.style.UnderlineColor = c
+			}
+		}
+	}
+}
+
+// Size returns the size of the screen.
+func (s *Screen) Size() (cols, rows int) {
+	return s.cols, s.rows
+}
+
+// Cursor returns the (0-based) cursor position.
+func (s *Screen) Cursor() (x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.x, s.y
+}
+
+// CursorVisible returns whether the cursor is shown.
+func (s *Screen) CursorVisible() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursorVisible
+}
+
+// Title returns the window title.
+func (s *Screen) Title() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.title
+}
+
+// Cell returns the cell at the (0-based) position.
+func (s *Screen) Cell(x, y int) Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cells[y][x]
+}
+
+// Line returns the text of row 'y', without trailing spaces.
+func (s *Screen) Line(y int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.line(y)
+}
+
+func (s *Screen) line(y int) string {
+	var b strings.Builder
+	for _, c := range s.cells[y] {
+		if c.Rune != 0 {
+			b.WriteRune(c.Rune)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Lines returns the text of all rows, without trailing spaces.
+func (s *Screen) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := make([]string, s.rows)
+	for y := range lines {
+		lines[y] = s.line(y)
+	}
+	return lines
+}
+
+// Snapshot returns the text on the screen; the rows are separated by line feeds,
+// without trailing spaces or trailing empty rows.
+func (s *Screen) Snapshot() string {
+	return strings.TrimRight(strings.Join(s.Lines(), "\n"), "\n")
+}
+
+// ANSISnapshot is like Snapshot, with SGR (and OSC 8) escape sequences where the style (or hyperlink) changes;
+// trailing spaces are only dropped if they have the default style.
+func (s *Screen) ANSISnapshot() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, s.rows)
+	for y, row := range s.cells {
+		end := len(row)
+		for end > 0 && row[end-1] == blankCell {
+			end--
+		}
+		var b strings.Builder
+		style, link := defaultStyle, ""
+		for _, c := range row[:end] {
+			if c.Link != link {
+				link = c.Link
+				b.WriteString("\x1b]8;;" + link + "\x1b\\")
+			}
+			if c.Style != style {
+				style = c.Style
+				b.WriteString(sgr(style))
+			}
+			if c.Rune != 0 {
+				b.WriteRune(c.Rune)
+			}
+		}
+		if link != "" {
+			b.WriteString("\x1b]8;;\x1b\\")
+		}
+		if style != defaultStyle {
+			b.WriteString("\x1b[0m")
+		}
+		lines[y] = b.String()
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// sgr returns the escape sequence selecting 'style' (from any other).
+func sgr(style Style) string {
+	params := []string{"0"}
+	if style.Bold {
+		params = append(params, "1")
+	}
+	if style.Faint {
+		params = append(params, "2")
+	}
+	if style.Italic {
+		params = append(params, "3")
+	}
+	if style.Underline {
+		if style.UnderlineStyle == prompt.UnderlineSingle {
+			params = append(params, "4")
+		} else {
+			params = append(params, fmt.Sprintf("4:%d", style.UnderlineStyle+1))
+		}
+	}
+	if style.Blink {
+		params = append(params, "5")
+	}
+	if style.Reverse {
+		params = append(params, "7")
+	}
+	if style.Conceal {
+		params = append(params, "8")
+	}
+	if style.CrossedOut {
+		params = append(params, "9")
+	}
+	if p := colorParam(style.Fg, 30, 90, "38"); p != "" {
+		params = append(params, p)
+	}
+	if p := colorParam(style.Bg, 40, 100, "48"); p != "" {
+		params = append(params, p)
+	}
+	if style.UnderlineColor != nil {
+		params = append(params, underlineColorParam(style.UnderlineColor))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+func colorParam(c prompt.Color, base, brightBase int, extended string) string {
+	switch c := c.(type) {
+	case prompt.RGBColor:
+		return fmt.Sprintf("%s;2;%d;%d;%d", extended, c.Red, c.Green, c.Blue)
+	case prompt.Ansi256Color:
+		return fmt.Sprintf("%s;5;%d", extended, c)
+	case prompt.AnsiColor:
+		switch {
+		case c >= prompt.Black && c < prompt.BrightBlack:
+			return strconv.Itoa(base + int(c-prompt.Black))
+		case c >= prompt.BrightBlack && c <= prompt.White:
+			return strconv.Itoa(brightBase + int(c-prompt.BrightBlack))
+		}
+	}
+	return ""
+}
+
+func underlineColorParam(c prompt.Color) string {
+	switch c := c.(type) {
+	case prompt.RGBColor:
+		return fmt.Sprintf("58;2;%d;%d;%d", c.Red, c.Green, c.Blue)
+	case prompt.Ansi256Color:
+		return fmt.Sprintf("58;5;%d", c)
+	}
+	return "59"
+}
+
+// WaitFor waits until 'cond' is true, re-checking it whenever there's output.
+// It returns false if that didn't happen within 'timeout'.
+func (s *Screen) WaitFor(timeout time.Duration, cond func(*Screen) bool) bool {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if cond(s) {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return cond(s)
+		}
+	}
+}
+
+// WaitForText waits until 'text' is on the screen; see WaitFor.
+func (s *Screen) WaitForText(text string, timeout time.Duration) bool {
+	return s.WaitFor(timeout, func(s *Screen) bool {
+		return strings.Contains(s.Snapshot(), text)
+	})
+}
@@ -0,0 +1,97 @@
+package prompttest
+
+import (
+	"reflect"
+	"testing"
+
+	prompt "github.com/tatsujin/go-prompt"
+)
+
+func TestScreen(t *testing.T) {
+	scenarioTable := []struct {
+		output   string
+		expected []string
+		x, y     int
+	}{
+		{output: "hello\nworld", expected: []string{"hello", "world", "", ""}, x: 5, y: 1},
+		{output: "abcdefghij", expected: []string{"abcdefgh", "ij", "", ""}, x: 2, y: 1},
+		{output: "abcdefgh", expected: []string{"abcdefgh", "", "", ""}, x: 7, y: 0},
+		{output: "abcdefgh\r\n", expected: []string{"abcdefgh", "", "", ""}, x: 0, y: 1},
+		{output: "1\n2\n3\n4\n5", expected: []string{"2", "3", "4", "5"}, x: 1, y: 3},
+		{output: "abcdef\x1b[3D\x1b[K", expected: []string{"abc", "", "", ""}, x: 3, y: 0},
+		{output: "abc\ndef\x1b[A\x1b[J", expected: []string{"abc", "", "", ""}, x: 3, y: 0},
+		{output: "abc\x1b[3;2Hx", expected: []string{"abc", "", " x", ""}, x: 2, y: 2},
+		{output: "a\x1b7bc\x1b8X", expected: []string{"aXc", "", "", ""}, x: 2, y: 0},
+		{output: "abcdefg日本", expected: []string{"abcdefg", "日本", "", ""}, x: 4, y: 1},
+		{output: "\x1b[31mred\x1b[0m\t|", expected: []string{"red    |", "", "", ""}, x: 7, y: 0},
+	}
+
+	for _, s := range scenarioTable {
+		scr := NewScreen(8, 4)
+		scr.Write([]byte(s.output))
+		if lines := scr.Lines(); !reflect.DeepEqual(lines, s.expected) {
+			t.Errorf("%q: Should be %#v, but got %#v", s.output, s.expected, lines)
+		}
+		if x, y := scr.Cursor(); x != s.x || y != s.y {
+			t.Errorf("%q: Should be (%d, %d), but got (%d, %d)", s.output, s.x, s.y, x, y)
+		}
+	}
+}
+
+func TestScreenStyle(t *testing.T) {
+	scr := NewScreen(20, 2)
+	// split in the middle of escape sequences and a UTF-8 encoding
+	for _, out := range []string{"\x1b[1;3", "4;41mA\x1b[0mB\x1b[38;2;1;2;3m\xe6", "\x97\xa5\x1b[0;2;4:3;58;5;1;7;9mC",
+		"\x1b[0m\x1b]8;;https://example.com\x1b\\D\x1b]8;;\x07E"} {
+		scr.Write([]byte(out))
+	}
+
+	scenarioTable := []struct {
+		x        int
+		expected Cell
+	}{
+		{x: 0, expected: Cell{Rune: 'A', Style: Style{Fg: prompt.Blue, Bg: prompt.Red, Bold: true}}},
+		{x: 1, expected: Cell{Rune: 'B', Style: defaultStyle}},
+		{x: 2, expected: Cell{Rune: '日', Style: Style{Fg: prompt.NewRGB(1, 2, 3), Bg: prompt.DefaultColor}}},
+		{x: 3, expected: Cell{Rune: 0, Style: Style{Fg: prompt.NewRGB(1, 2, 3), Bg: prompt.DefaultColor}}},
+		{x: 4, expected: Cell{Rune: 'C', Style: Style{Fg: prompt.DefaultColor, Bg: prompt.DefaultColor, Faint: true,
+			Underline: true, UnderlineStyle: prompt.UnderlineCurly, UnderlineColor: prompt.Ansi256Color(1), Reverse: true, CrossedOut: true}}},
+		{x: 5, expected: Cell{Rune: 'D', Style: defaultStyle, Link: "https://example.com"}},
+		{x: 6, expected: Cell{Rune: 'E', Style: defaultStyle}},
+	}
+
+	for _, s := range scenarioTable {
+		if c := scr.Cell(s.x, 0); !reflect.DeepEqual(c, s.expected) {
+			t.Errorf("Should be %#v, but got %#v", s.expected, c)
+		}
+	}
+}
+
+func TestScreenResponse(t *testing.T) {
+	scr := NewScreen(20, 4)
+	var responses []string
+	scr.OnResponse = func(s string) {
+		responses = append(responses, s)
+	}
+	scr.Write([]byte("ab\ncd\x1b[6n\x1b]0;title\x07\x1b[?25l\x1b[>0q\x1b[c"))
+
+	if expected := []string{"\x1b[2;3R", "\x1bP>|prompttest\x1b\\", "\x1b[?62;22c"}; !reflect.DeepEqual(responses, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, responses)
+	}
+	if title := scr.Title(); title != "title" {
+		t.Errorf("Should be %#v, but got %#v", "title", title)
+	}
+	if scr.CursorVisible() {
+		t.Errorf("Should be false, but got true")
+	}
+}
+
+func TestScreenANSISnapshot(t *testing.T) {
+	scr := NewScreen(20, 4)
+	scr.Write([]byte("> \x1b[1;31mab\x1b[0m c\r\n\x1b[47m  \x1b[0m\r\n"))
+
+	expected := "> \x1b[0;1;31mab\x1b[0m c\n\x1b[0;47m  \x1b[0m"
+	if actual := scr.ANSISnapshot(); actual != expected {
+		t.Errorf("Should be %q, but got %q", expected, actual)
+	}
+}
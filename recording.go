@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tatsujin/go-prompt/internal/debug"
+)
+
+// Sessions are recorded in the asciicast v2 format (https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md):
+// a JSON header line, then one JSON array per event: [time in seconds, type, data].
+// Event types are "i" for input (one per ConsoleParser.Read), "o" for output (one per ConsoleWriter.Flush)
+// and "r" for a changed terminal size ("COLSxROWS").
+// Since the data is a JSON string, bytes that aren't valid UTF-8 are replaced with U+FFFD.
+
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// recorder writes the events of a session to a file.
+type recorder struct {
+	mu      sync.Mutex
+	out     io.Writer
+	winSize func() *WinSize
+	size    WinSize
+	start   time.Time // zero until the header has been written
+	err     error     // the first write error, which stops the recording
+}
+
+func newRecorder(out io.Writer, winSize func() *WinSize) *recorder {
+	return &recorder{
+		out:     out,
+		winSize: winSize,
+	}
+}
+
+// event records an event; the header is written before the first one.
+func (r *recorder) event(kind, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.start.IsZero() {
+		r.start = time.Now()
+		r.size = *r.winSize()
+		r.write(castHeader{
+			Version:   2,
+			Width:     int(r.size.Col),
+			Height:    int(r.size.Row),
+			Timestamp: r.start.Unix(),
+		})
+	}
+	t := math.Round(time.Since(r.start).Seconds()*1e6) / 1e6
+	r.write([]interface{}{t, kind, data})
+}
+
+// resize records a changed terminal size.
+func (r *recorder) resize(ws *WinSize) {
+	r.mu.Lock()
+	changed := !r.start.IsZero() && *ws != r.size
+	r.size = *ws
+	r.mu.Unlock()
+
+	if changed {
+		r.event("r", fmt.Sprintf("%dx%d", ws.Col, ws.Row))
+	}
+}
+
+func (r *recorder) write(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err == nil {
+		_, err = r.out.Write(append(b, '\n'))
+	}
+	if err != nil {
+		debug.Log("recording stopped: " + err.Error())
+		r.err = err
+	}
+}
+
+// recordingParser records the input and size changes of a ConsoleParser.
+type recordingParser struct {
+	ConsoleParser
+	rec *recorder
+}
+
+// Read returns the input of the wrapped parser.
+func (p *recordingParser) Read() ([]byte, error) {
+	b, err := p.ConsoleParser.Read()
+	if err == nil && len(b) > 0 && !(len(b) == 1 && b[0] == 0) {
+		p.rec.event("i", string(b))
+	}
+	return b, err
+}
+
+// GetWinSize returns the size from the wrapped parser.
+func (p *recordingParser) GetWinSize() *WinSize {
+	ws := p.ConsoleParser.GetWinSize()
+	p.rec.resize(ws)
+	return ws
+}
+
+// cancelableRecordingParser is a recordingParser for a CancelableParser.
+type cancelableRecordingParser struct {
+	*recordingParser
+}
+
+// Cancel cancels the wrapped parser's Read.
+func (p *cancelableRecordingParser) Cancel() error {
+	return p.ConsoleParser.(CancelableParser).Cancel()
+}
+
+// recordingWriter records the output of a ConsoleWriter;
+// that's only possible for writers based on VT100Writer.
+type recordingWriter struct {
+	ConsoleWriter
+	rec *recorder
+}
+
+// Flush flushes the wrapped writer.
+func (w *recordingWriter) Flush() error {
+	if b, ok := w.ConsoleWriter.(interface{ buffered() []byte }); ok && len(b.buffered()) > 0 {
+		w.rec.event("o", string(b.buffered()))
+	}
+	return w.ConsoleWriter.Flush()
+}
+
+// startRecording makes the prompt record its input and output to 'out'.
+func (p *Prompt) startRecording(out io.Writer) {
+	rp := &recordingParser{ConsoleParser: p.in}
+	rec := newRecorder(out, rp.ConsoleParser.GetWinSize)
+	rp.rec = rec
+	if _, ok := p.in.(CancelableParser); ok {
+		p.in = &cancelableRecordingParser{rp}
+	} else {
+		p.in = rp
+	}
+	p.renderer.out = &recordingWriter{ConsoleWriter: p.renderer.out, rec: rec}
+}
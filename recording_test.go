@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var rec bytes.Buffer
+	in := newTestParser()
+	for _, b := range []string{"ab", "\x02", "x", "\r"} {
+		in.input <- []byte(b)
+	}
+	p := New(func(string) {}, func(Document) []Choice { return nil },
+		OptionParser(in),
+		OptionWriter(&discardWriter{}),
+		OptionRecord(&rec),
+	)
+	if text, err := p.InputContext(context.Background()); text != "axb" || err != nil {
+		t.Fatalf("Should be %#v (%v), but got %#v (%v)", "axb", nil, text, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.String()), "\n")
+	var header castHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if expected := (castHeader{Version: 2, Width: 80, Height: 24, Timestamp: header.Timestamp}); !reflect.DeepEqual(header, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, header)
+	}
+	var input []string
+	output := 0
+	for _, line := range lines[1:] {
+		var ev []interface{}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Should be nil, but got %v", err)
+		}
+		switch ev[1] {
+		case "i":
+			input = append(input, ev[2].(string))
+		case "o":
+			output++
+		}
+	}
+	if expected := []string{"ab", "\x02", "x", "\r"}; !reflect.DeepEqual(input, expected) {
+		t.Errorf("Should be %#v, but got %#v", expected, input)
+	}
+	if output == 0 {
+		t.Errorf("Should record output")
+	}
+
+	replay, err := NewReplayParser(&rec, 0)
+	if err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if text, err := newInputTestPrompt(replay).InputContext(context.Background()); text != "axb" || err != nil {
+		t.Errorf("Should be %#v (%v), but got %#v (%v)", "axb", nil, text, err)
+	}
+}
+
+func TestNewReplayParserErrors(t *testing.T) {
+	scenarioTable := []struct {
+		recording string
+		expected  string
+	}{
+		{recording: "", expected: "recording: missing header"},
+		{recording: `{"version": 1}`, expected: "recording: unsupported version 1"},
+		{recording: "{\"version\": 2}\n[0.5, \"i\"]", expected: "recording:2: invalid event"},
+		{recording: "{\"version\": 2}\n[0.5, \"r\", \"80\"]", expected: "recording:2: invalid size \"80\""},
+	}
+
+	for _, s := range scenarioTable {
+		_, err := NewReplayParser(strings.NewReader(s.recording), 0)
+		if err == nil || err.Error() != s.expected {
+			t.Errorf("Should be %#v, but got %v", s.expected, err)
+		}
+	}
+}
+
+func TestReplayResize(t *testing.T) {
+	recording := `{"version": 2, "width": 80, "height": 24}
+[0.1, "i", "ab"]
+[0.2, "r", "40x10"]
+[0.3, "i", "c"]
+[0.4, "r", "60x12"]
+`
+	replay, err := NewReplayParser(strings.NewReader(recording), 0)
+	if err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	p := newInputTestPrompt(replay)
+	if text, err := p.InputContext(context.Background()); text != "" || err != ErrEOF {
+		t.Errorf("Should be %#v (%v), but got %#v (%v)", "", ErrEOF, text, err)
+	}
+	if p.buf.Text() != "abc" {
+		t.Errorf("Should be %#v, but got %#v", "abc", p.buf.Text())
+	}
+	if p.renderer.termWidth != 60 || p.renderer.termHeight != 12 {
+		t.Errorf("Should be %v, but got %vx%v", "60x12", p.renderer.termWidth, p.renderer.termHeight)
+	}
+}
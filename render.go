@@ -4,78 +4,84 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/tatsujin/go-prompt/internal/debug"
 	runewidth "github.com/mattn/go-runewidth"
+	"github.com/tatsujin/go-prompt/internal/debug"
 )
 
 // Render to render prompt information from state of Buffer.
 type Render struct {
 	out ConsoleWriter
 	//cursor             Cursor
-	prefix             string
-	prefixCallback     func(doc *Document, row Row) (prefix string, usePrefix bool)
-	continuationPrefix string
-	suffix             string
-	suffixCallback     func(doc *Document, row Row) (prefix string, usePrefix bool)
+	prefix             StyledText
+	prefixCallback     func(doc *Document, row Row) (prefix StyledText, usePrefix bool)
+	continuationPrefix StyledText
+	suffix             StyledText
+	suffixCallback     func(doc *Document, row Row) (suffix StyledText, usePrefix bool)
 	title              string
 	termHeight         Row
 	termWidth          Column
 
-	previousCursor    Coord
-	previousLineCount int
+	// the displayed frame, and where the cursor was left (relative to the prompt's home position)
+	previous       *frame
+	previousCursor Coord
+	rowsAllocated  Row // rows from the home position that exist on the screen
+	viewportTop    Row // the first row of the text shown, if it's taller than the window
+
+	lineNumbers      bool
+	scrollIndicators bool
+
+	// wrap output in synchronized updates (so terminals don't show half-drawn frames)
+	syncOutput bool
+
+	theme      Theme
+	colorDepth ColorDepth
+	link       string // the URL of the hyperlink the text is written in, if any
+
+	// underline styles and colors are used (with 24-bit colors), see OptionStyledUnderlines
+	styledUnderlines bool
 
-	Colors             RenderColors
-	trueColorSupported bool
+	keyboardProtocol KeyboardProtocol
+	mouse            bool
+	probing          bool // nothing is rendered while waiting for the terminal to answer the probe
+
+	// a partial line (output that didn't end with a newline) is ended with the marker, instead of written over
+	keepPartialLine   bool
+	partialLineMarker StyledText
+
+	// shown below the input, e.g. a partially typed key sequence
+	status string
+
+	// the segments of the toolbar shown below everything else (nil if there's none)
+	toolbar func(doc *Document) StyledText
+
+	// how the input is left when it's accepted (see OptionTransientPrompt)
+	transient TransientPrompt
+
+	// where the completion menu was last rendered, and the index of its first visible choice
+	completionArea  area
+	completionFirst int
 
 	outputLock *sync.Mutex
 }
 
-type RenderColors struct {
-	prefixText              Color
-	prefixBG                Color
-	inputText               Color
-	inputBG                 Color
-	choiceText              Color
-	choiceBG                Color
-	descriptionText         Color
-	descriptionBG           Color
-	selectedChoiceText      Color
-	selectedChoiceBG        Color
-	selectedDescriptionText Color
-	selectedDescriptionBG   Color
-	previewChoiceText       Color
-	previewChoiceBG         Color
-	scrollbarThumb          Color
-	scrollbarBG             Color
-}
-
-// these should only use ANSI colors
-// TODO: set unspecified ones to DefaultColor (must use reflect)
-//       and remove the check in SetDisplayAttributes
-var defaultColors = RenderColors{
-	choiceText:              Black,
-	choiceBG:                Gray,
-	descriptionText:         BrightBlack,
-	selectedChoiceText:      White,
-	selectedChoiceBG:        Blue,
-	selectedDescriptionText: Gray,
-	previewChoiceText:       White,
-	scrollbarThumb:          BrightBlack,
-}
-
-var nilPrefix = func(*Document, Row) (string, bool) { return "", false }
+var nilPrefix = func(*Document, Row) (StyledText, bool) { return nil, false }
 
 func NewRender(prefix string, w ConsoleWriter) *Render {
 	r := &Render{
-		prefix: prefix,
+		prefix: plainText(prefix),
 		out:    w,
 		//cursor: NewCursor(w),
-		Colors: defaultColors,
+		theme:            DarkTheme,
+		colorDepth:       DetectColorDepth(),
+		styledUnderlines: true,
 
-		previousLineCount: 1,
+		previous:      newFrame(0),
+		rowsAllocated: 1,
+		syncOutput:    runtime.GOOS != "windows",
 
 		prefixCallback: nilPrefix,
 		suffixCallback: nilPrefix,
@@ -83,21 +89,14 @@ func NewRender(prefix string, w ConsoleWriter) *Render {
 		outputLock: &sync.Mutex{},
 	}
 
-	// https://gist.github.com/XVilka/8346728#detection
-	cterm := os.Getenv("COLORTERM")
-	if cterm == "truecolor" || cterm == "24bit" {
-		r.trueColorSupported = true
-	}
-
 	return r
 }
 
+// ValidateColor returns 'c' as it's displayed with the terminal's color depth,
+// and whether that's the same color.
 func (r *Render) ValidateColor(c Color) (Color, bool) {
-	if r.trueColorSupported || !c.IsTrueColor() {
-		return c, true
-	}
-	// TODO: make fallback color?
-	return nil, false
+	v := r.colorDepth.Convert(c)
+	return v, v == c
 }
 
 // Setup to initialize console output.
@@ -106,10 +105,13 @@ func (r *Render) Setup() {
 		r.out.SetTitle(r.title)
 		debug.AssertNoError(r.out.Flush())
 	}
+	r.setTerminalModes(true)
 }
 
 // TearDown to clear title and erasing.
 func (r *Render) TearDown() {
+	r.setTerminalModes(false)
+
 	r.outputLock.Lock()
 	defer r.outputLock.Unlock()
 
@@ -118,6 +120,41 @@ func (r *Render) TearDown() {
 	debug.AssertNoError(r.out.Flush())
 }
 
+// setTerminalModes enables (or disables) the terminal input modes requested by the options,
+// e.g. the keyboard protocol. They must be disabled while other programs use the terminal.
+func (r *Render) setTerminalModes(enabled bool) {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+
+	var seq string
+	if enabled {
+		seq = r.keyboardProtocol.enableSequence()
+		if r.mouse {
+			seq += mouseTrackingEnable
+		}
+	} else {
+		seq = r.keyboardProtocol.disableSequence()
+		if r.mouse {
+			seq += mouseTrackingDisable
+		}
+	}
+	if seq == "" {
+		return
+	}
+	r.out.WriteRawStr(seq)
+	debug.AssertNoError(r.out.Flush())
+}
+
+// requestCursorPosition asks the terminal to report the cursor position.
+// The response arrives as input.
+func (r *Render) requestCursorPosition() {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+
+	r.out.AskForCPR()
+	debug.AssertNoError(r.out.Flush())
+}
+
 // UpdateWinSize called when window size is changed.
 func (r *Render) UpdateWinSize(ws *WinSize) {
 	r.outputLock.Lock()
@@ -147,85 +184,114 @@ func (r *Render) Render(buffer *Buffer, compMgr *CompletionManager) {
 func (r *Render) render(buf *Buffer, compMgr *CompletionManager) {
 	// In situations where a pseudo tty is allocated (e.g. within a docker container),
 	// window size via TIOCGWINSZ is not immediately available and will result in 0,0 dimensions.
-	if r.termWidth == 0 {
+	if r.termWidth == 0 || r.probing {
 		return
 	}
 
 	doc := buf.Document()
 
-	// TODO: this should render into an off-screen buffer.
-	//   this buffer would then be compared with the previously rendered buffer
-	//   and generate actual output instructions from that diff.
-
 	defer func() { debug.AssertNoError(r.out.Flush()) }()
 
-	// if lines have been added to the edit, add space
-	lcount := doc.LineCount()
-	added := 0
-	if lcount > r.previousLineCount {
-		r.out.WriteRaw([]byte{'\n'})
-		added = 1
+	if r.previous.width != r.termWidth {
+		// the terminal has been resized; the previous frame can't be updated
+		r.moveCursor(r.previousCursor, Coord{})
+		r.setStyle(defaultStyle)
+		r.out.EraseDown()
+		r.resetFrame()
+	}
+
+	// if a completion choice is currently selected, show it in place of the word being completed
+	// -- but do NOT change the editor content!
+	var word, preview string
+	if choice, ok := compMgr.Selected(); ok {
+		word = doc.GetWordBeforeCursorUntilSeparator(compMgr.wordSeparator)
+		preview = choice.Text
 	}
 
-	// move to beginning of the current prompt
-	r.promptHome(Coord{r.previousCursor.X, r.previousCursor.Y + Row(added)})
+	text := newFrame(r.termWidth)
+	editPoint, l := r.layoutPrompt(text, doc, word, preview, false)
+	r.layoutSuffix(text, doc, l)
+	// the menu is placed as if there was no preview
+	menuPoint, _ := r.layoutPrompt(newFrame(r.termWidth), doc, "", "", false)
+
+	// the rows needed below the text
+	var below Row
+	if compMgr.NumChoices() > 0 {
+		r.layoutCompletion(newFrame(r.termWidth), compMgr, Coord{menuPoint.X, -1})
+		below = r.completionArea.height
+	} else if r.status != "" {
+		below = 1
+	}
+	var toolbar StyledText
+	if r.toolbar != nil {
+		toolbar = r.toolbar(doc)
+	}
+	if len(toolbar) > 0 {
+		below++
+	}
 
-	// prepare area
-	h := Row(lcount + 1 + int(compMgr.MaxVisibleChoices()))
-	if h > r.termHeight || completionMargin > r.termWidth {
+	height := r.termHeight - below
+	if height < 1 || completionMargin > r.termWidth {
 		r.renderWindowTooSmall()
 		return
 	}
+	top := r.scrollViewport(Row(len(text.rows)), height, editPoint.Y)
 
-	// Rendering
-	r.out.HideCursor()
-	defer r.out.ShowCursor()
-
-	r.out.SaveCursor()
-
-	// render the complete prompt; prefix and editor content
-	r.out.EraseDown()
-	r.renderPrompt(doc, false, false)
-
-	// calculate "working" cursor position after prefix & line is printed
-	editPoint := doc.CursorDisplayCoordWithPrefix(Column(r.termWidth), r.getPrefix)
-
-	// position the cursor at the edit point after the rendering
-	r.out.RestoreCursor()
-	r.move(Coord{}, editPoint)
+	f := newFrame(r.termWidth)
+	f.rows = text.rows[top:]
+	if Row(len(f.rows)) > height {
+		f.rows = f.rows[:height]
+	}
+	if r.scrollIndicators {
+		if top > 0 {
+			f.put(Coord{f.width - 1, 0}, '▲', 1, r.theme.Style(RoleScrollIndicator))
+		}
+		if top+height < Row(len(text.rows)) {
+			f.put(Coord{f.width - 1, height - 1}, '▼', 1, r.theme.Style(RoleScrollIndicator))
+		}
+	}
+	if compMgr.NumChoices() > 0 {
+		r.layoutCompletion(f, compMgr, Coord{menuPoint.X, menuPoint.Y - top})
+	} else {
+		r.layoutStatus(f)
+	}
+	if len(toolbar) > 0 {
+		r.layoutToolbar(f, toolbar)
+	}
 
-	r.renderCompletion(buf, compMgr)
+	f.cursor = Coord{editPoint.X, editPoint.Y - top}
+	r.paint(f)
+}
 
-	// if a completion choice is currently selected, update the screen -- but NOT the editor content!
-	if choice, ok := compMgr.Selected(); ok {
-		// move to the beginning of the word being completed
-		completing_word := doc.GetWordBeforeCursorUntilSeparator(compMgr.wordSeparator)
-		editPoint = r.move(editPoint, Coord{Column(-runewidth.StringWidth(completing_word)), 0})
-
-		// write the choice, using the configured preview style
-		r.out.SetColor(r.Colors.previewChoiceText, r.Colors.previewChoiceBG, false)
-		r.out.WriteStr(choice.Text)
-		// move edit point to the end of the suggested word
-		editPoint.X += Column(runewidth.StringWidth(choice.Text))
-		r.out.SaveCursor()
-
-		// write the text following the cursor (using default style)
-		r.out.SetColor(DefaultColor, DefaultColor, false)
-		rest := buf.Document().TextAfterCursor()
-		r.out.WriteStr(rest)
-		// total length of line
-		eol := editPoint.X + Column(runewidth.StringWidth(rest))
-		// move cursor back to the edit point
-		if r.lineWrap(eol) { // output LF if necessary
-			r.out.RestoreCursor()
-			r.out.CursorUp(1)
-		} else {
-			r.out.RestoreCursor()
-		}
+// scrollViewport returns the first row of the text to show, if it's taller than 'height' rows;
+// the viewport only scrolls as far as needed to show the cursor's row.
+func (r *Render) scrollViewport(rows, height, cursor Row) Row {
+	top := r.viewportTop
+	if cursor < top {
+		top = cursor
+	} else if cursor >= top+height {
+		top = cursor - height + 1
+	}
+	if top > rows-height {
+		top = rows - height
 	}
+	if top < 0 {
+		top = 0
+	}
+	r.viewportTop = top
+	return top
+}
+
+// RenderError writes an error on a line of its own (e.g. below the input after BreakLine).
+func (r *Render) RenderError(err error) {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
 
-	r.previousCursor = editPoint
-	r.previousLineCount = 1 //lcount
+	r.setStyle(r.theme.Style(RoleError))
+	r.out.WriteStr(err.Error())
+	r.setStyle(defaultStyle)
+	r.out.WriteRawStr("\n")
+	debug.AssertNoError(r.out.Flush())
 }
 
 // BreakLine to break line.
@@ -233,180 +299,241 @@ func (r *Render) BreakLine(buf *Buffer, cancelled bool) {
 	r.outputLock.Lock()
 	defer r.outputLock.Unlock()
 
-	// Erasing and Render
-	doc := buf.Document()
-	editPoint := doc.CursorDisplayCoordWithPrefix(r.termWidth, r.getPrefix)
-	r.promptHome(editPoint)
+	// render the whole input (without the suffix, the completion menu etc) from the top of the viewport, and move below it;
+	// the frame is written downwards, so it may be taller than the window
+	r.moveCursor(r.previousCursor, Coord{})
+	r.setStyle(defaultStyle)
 	r.out.EraseDown()
-	r.renderPrompt(doc, true, cancelled)
+	r.resetFrame()
+
+	f := newFrame(r.termWidth)
+	if t, ok := r.transientPrompt(buf.Document(), cancelled); ok {
+		r.layoutTransient(f, buf.Document(), t)
+	} else {
+		r.layoutPrompt(f, buf.Document(), "", "", cancelled)
+	}
+	f.cursor = Coord{0, Row(len(f.rows))}
+	r.paint(f)
 	debug.AssertNoError(r.out.Flush())
 
-	r.previousCursor = Coord{}
-	r.previousLineCount = 1
+	r.resetFrame()
+	r.viewportTop = 0
 }
 
+// transientPrompt returns how to leave the accepted input 'doc', if OptionTransientPrompt is set.
+func (r *Render) transientPrompt(doc *Document, cancelled bool) (Transient, bool) {
+	if r.transient == nil {
+		return Transient{}, false
+	}
+	return r.transient(doc, cancelled)
+}
+
+// OutputAsync writes text above the prompt.
 func (r *Render) OutputAsync(buf *Buffer, compMgr *CompletionManager, format string, a ...interface{}) {
 	go func() {
 		r.outputLock.Lock()
+		defer r.outputLock.Unlock()
 		buf.RLock()
+		defer buf.RUnlock()
 
-		r.out.SaveCursor()
-
-		r.promptHome(r.previousCursor)
+		r.moveCursor(r.previousCursor, Coord{})
+		r.setStyle(defaultStyle)
 		r.out.EraseDown()
 
 		text := fmt.Sprintf(format, a...)
-		r.out.SetColor(r.Colors.inputText, r.Colors.inputBG, false)
+		r.setStyle(r.theme.Style(RoleInput))
 		r.out.WriteRawStr(text)
+		r.setStyle(defaultStyle)
+		if strings.Contains(text, hyperlinkStart) {
+			r.out.SetHyperlink("") // so an unended hyperlink doesn't run into the prompt
+		}
 		// force LF
-		outputLines := strings.Count(text, "\n")
-		if text[len(text)-1] != '\n' {
+		if !strings.HasSuffix(text, "\n") {
 			r.out.WriteRawStr("\n")
-			outputLines++
 		}
-		r.out.RestoreCursor()
-		r.out.CursorDown(outputLines)
 
-		buf.RLock()
+		// the prompt is rendered again below the text
+		r.resetFrame()
 		r.render(buf, compMgr)
-		buf.RUnlock()
-
-		buf.RUnlock()
-		r.outputLock.Unlock()
 	}()
 }
 
-func (r *Render) prepareArea(lines Row) {
-	for i := Row(0); i < lines; i++ {
-		r.out.ScrollDown()
+// layoutPrompt lays out the prefixes and the text of 'doc', and returns the position of the cursor and the layout.
+// If 'preview' isn't empty, it's shown in place of 'word' (just before the cursor),
+// and the cursor is placed after it.
+func (r *Render) layoutPrompt(f *frame, doc *Document, word, preview string, cancelled bool) (Coord, *textLayout) {
+	prefixStyle := r.theme.Style(RolePrefix)
+	inputStyle := r.theme.Style(RoleInput)
+	if cancelled {
+		// TODO: if the text contains styling, disable that also
+		prefixStyle = r.theme.Style(RoleCancelled)
+		inputStyle = r.theme.Style(RoleCancelled)
 	}
-	for i := Row(0); i < lines; i++ {
-		r.out.ScrollUp()
+	previewStyle := r.theme.Style(RolePreviewChoice)
+
+	// the runes of the preview
+	from, to := -1, -1
+	if preview != "" {
+		before := []rune(doc.TextBeforeCursor())
+		from = len(before) - len([]rune(word))
+		to = from + len([]rune(preview))
+		doc = NewDocument(string(before[:from])+preview+doc.TextAfterCursor(), to)
 	}
-}
-
-var LFstr = "\n"
-
-func (r *Render) renderPrompt(doc *Document, breakLine bool, cancelled bool) {
 
-	// TODO: syntax highlight of document text
-	//   probably should make something akin to the "formatted text" in prompt-toolkit
-
-	lines := doc.Lines()
-	for row, line := range lines {
+	l := layoutText(doc, f.width, r.linePrefix)
+	for row, line := range l.lines {
+		pos := f.write(line.home, r.lineNumber(doc, Row(row)), r.theme.Style(RoleLineNumber))
+		prefix := r.getPrefix(doc, Row(row))
 		if cancelled {
-			r.out.SetColor(BrightBlack, DefaultColor, false)
-			// TODO: if the prefix contains styling, disable that also
+			prefix = plainText(prefix.String())
+		}
+		f.writeStyled(pos, prefix, prefixStyle)
+	}
+	for i, ch := range doc.text {
+		w := Column(runewidth.RuneWidth(ch))
+		if w == 0 || w > f.width {
+			continue // line feeds, control characters etc
+		}
+		if i >= from && i < to {
+			f.put(l.runes[i], ch, w, previewStyle)
 		} else {
-			r.out.SetColor(r.Colors.prefixText, r.Colors.prefixBG, false)
+			f.put(l.runes[i], ch, w, inputStyle)
 		}
-		r.out.WriteRawStr(r.getPrefix(doc, Row(row)))
+	}
+	f.row(l.rows() - 1)
+	return l.cursor(doc.cursor), l
+}
 
-		if cancelled {
-			r.out.SetColor(BrightBlack, DefaultColor, false)
-			// TODO: if the text contains styling, disable that also
-		} else {
-			r.out.SetColor(r.Colors.inputText, r.Colors.inputBG, false)
+// rightPromptIndent is the number of columns left free right of the suffix,
+// so it never ends in the last column (which wraps differently across terminals).
+const rightPromptIndent = 1
+
+// layoutSuffix lays out the suffixes right-aligned on the first row of their lines (laid out in 'l'),
+// like a zsh RPROMPT; a suffix is hidden if the line would overlap it.
+func (r *Render) layoutSuffix(f *frame, doc *Document, l *textLayout) {
+	style := r.theme.Style(RolePrefix)
+	for row, line := range l.lines {
+		suffix := r.getSuffix(doc, Row(row))
+		if suffix.Width() == 0 {
+			continue
 		}
-		r.out.WriteRawStr(line)
-		if row != len(lines)-1 {
-			r.out.WriteRawStr(LFstr)
+		x := f.width - Column(suffix.Width()) - rightPromptIndent
+		// keep a blank column between the text (and the cursor after it) and the suffix
+		if line.textEnd.Y != line.home.Y || line.textEnd.X+1 >= x {
+			continue
 		}
+		f.writeStyled(Coord{x, line.home.Y}, suffix, style)
 	}
+}
 
-	if breakLine {
-		r.out.WriteRawStr(LFstr)
+// layoutStatus lays out the status text on the row below the input.
+func (r *Render) layoutStatus(f *frame) {
+	if r.status == "" {
+		return
 	}
+	f.write(Coord{0, Row(len(f.rows))}, r.status, r.theme.Style(RoleStatus))
 }
 
+// synchronized output (https://gist.github.com/christianparpart/d8a62cc1ab659194337d73e399004036);
+// terminals that don't support it ignore these
+const (
+	syncOutputBegin = "\x1b[?2026h"
+	syncOutputEnd   = "\x1b[?2026l"
+)
+
 const scrollbarWidth = 1
 const safetyMargin = 1
 
-func (r *Render) renderCompletion(buf *Buffer, compMgr *CompletionManager) {
+// layoutCompletion lays out the completion menu below 'editPoint'.
+func (r *Render) layoutCompletion(f *frame, compMgr *CompletionManager, editPoint Coord) {
+	r.completionArea = area{}
 	if compMgr.NumChoices() == 0 {
 		return
 	}
 
-	editPoint := buf.Document().CursorDisplayCoordWithPrefix(r.termWidth, r.getPrefix)
-
 	widthLimit := r.termWidth - editPoint.X - scrollbarWidth - safetyMargin
 
 	formatted, width, withDesc := compMgr.FormatChoices(widthLimit, r.termWidth)
 	width += scrollbarWidth
 
-	windowHeight := Row(len(formatted))
-	if windowHeight > Row(compMgr.MaxVisibleChoices()) {
-		windowHeight = Row(compMgr.MaxVisibleChoices())
-	}
-
-	var cursorMoved Column
-
+	x := editPoint.X
 	if r.termWidth-editPoint.X < 40 || editPoint.X+width >= r.termWidth {
-		cursorMoved = -editPoint.X + 10 // say, at column 10 :)
-		r.move(Coord{}, Coord{cursorMoved, 0})
+		x = 10 // say, at column 10 :)
 		// re-format the choices, we now have more space
-		widthLimit = r.termWidth - (editPoint.X - cursorMoved) - scrollbarWidth - safetyMargin
+		widthLimit = r.termWidth - x - scrollbarWidth - safetyMargin
 		formatted, width, withDesc = compMgr.FormatChoices(widthLimit, r.termWidth)
 		width += scrollbarWidth
 	}
 
+	if len(formatted) == 0 {
+		return
+	}
+	windowHeight := Row(len(formatted))
+	if windowHeight > Row(compMgr.MaxVisibleChoices()) {
+		windowHeight = Row(compMgr.MaxVisibleChoices())
+	}
+
 	formatted = formatted[compMgr.verticalScroll : compMgr.verticalScroll+int(windowHeight)]
-	r.prepareArea(windowHeight)
+
+	r.completionArea = area{
+		origin: Coord{x, editPoint.Y + 1},
+		width:  width - scrollbarWidth,
+		height: windowHeight,
+	}
+	r.completionFirst = compMgr.verticalScroll
 
 	// compute scrollbar parameters
 	contentHeight := compMgr.NumChoices()
 	fractionVisible := float64(windowHeight) / float64(contentHeight)
-	fractionAbove := float64(compMgr.verticalScroll) / float64(contentHeight)
 
 	scrollbarHeight := int(clamp(float64(windowHeight), 1, float64(windowHeight)*fractionVisible))
-	scrollbarTop := int(float64(windowHeight) * fractionAbove)
+	scrollbarTop := 0
+	if maxScroll := contentHeight - int(windowHeight); maxScroll > 0 {
+		// the thumb reaches the bottom when the last choice is shown
+		scrollbarTop = (int(windowHeight) - scrollbarHeight) * compMgr.verticalScroll / maxScroll
+	}
 
 	isScrollThumb := func(row int) bool {
-		return scrollbarTop <= row && row <= scrollbarTop+scrollbarHeight
+		return scrollbarTop <= row && row < scrollbarTop+scrollbarHeight
 	}
 
 	selected := compMgr.selected - compMgr.verticalScroll
 
 	for i := 0; i < int(windowHeight); i++ {
-		r.out.CursorDown(1)
+		pos := Coord{x, editPoint.Y + 1 + Row(i)}
 
 		// draw choice text
 		if i == selected {
-			r.out.SetColor(r.Colors.selectedChoiceText, r.Colors.selectedChoiceBG, true)
+			pos = f.write(pos, formatted[i].Text, r.theme.Style(RoleSelectedChoice))
 		} else {
-			r.out.SetColor(r.Colors.choiceText, r.Colors.choiceBG, false)
+			pos = f.write(pos, formatted[i].Text, r.theme.Style(RoleChoice))
 		}
-		r.out.WriteStr(formatted[i].Text)
 
 		if withDesc { // might be skipped if we don't have space
 			// draw choice description
 			if i == selected {
-				r.out.SetColor(r.Colors.selectedDescriptionText, r.Colors.selectedDescriptionBG, false)
+				f.write(pos, formatted[i].Description, r.theme.Style(RoleSelectedDescription))
 			} else {
-				r.out.SetColor(r.Colors.descriptionText, r.Colors.descriptionBG, false)
+				f.write(pos, formatted[i].Description, r.theme.Style(RoleDescription))
 			}
-			r.out.WriteStr(formatted[i].Description)
 		}
 
+		// draw the scrollbar
+		pos.X = x + width - scrollbarWidth
+		if pos.X >= f.width {
+			continue
+		}
 		if isScrollThumb(i) {
-			r.out.SetColor(DefaultColor, r.Colors.scrollbarThumb, false)
+			f.put(pos, ' ', scrollbarWidth, r.theme.Style(RoleScrollbarThumb))
 		} else {
-			r.out.SetColor(DefaultColor, r.Colors.scrollbarBG, false)
+			f.put(pos, ' ', scrollbarWidth, r.theme.Style(RoleScrollbar))
 		}
-		r.out.SetColor(DefaultColor, DefaultColor, false)
-
-		r.move(Coord{}, Coord{-width + 1, 0})
 	}
-
-	// move back to edit point (use RestoreCursor?)
-	r.move(Coord{}, Coord{-cursorMoved, -windowHeight})
-
-	r.out.SetColor(DefaultColor, DefaultColor, false)
 }
 
 // getPrefix to get current prefix.
 // If prefix callback is set, use that.
-func (r *Render) getPrefix(doc *Document, row Row) string {
+func (r *Render) getPrefix(doc *Document, row Row) StyledText {
 	if prefix, ok := r.prefixCallback(doc, row); ok {
 		return prefix
 	}
@@ -416,34 +543,226 @@ func (r *Render) getPrefix(doc *Document, row Row) string {
 	return r.continuationPrefix
 }
 
-// getRightPrefix to get current right prefix.
-// If prefix callback is set, use that.
-func (r *Render) getSuffix(doc *Document, row Row) string {
+// lineNumber returns the line number shown before the prefix of 'row' (see OptionLineNumbers).
+func (r *Render) lineNumber(doc *Document, row Row) string {
+	if !r.lineNumbers {
+		return ""
+	}
+	return fmt.Sprintf("%*d ", len(strconv.Itoa(doc.LineCount())), row+1)
+}
+
+// linePrefix returns everything displayed before the text of 'row'.
+func (r *Render) linePrefix(doc *Document, row Row) StyledText {
+	return append(plainText(r.lineNumber(doc, row)), r.getPrefix(doc, row)...)
+}
+
+// getSuffix to get the suffix shown right of 'row'.
+// If suffix callback is set, use that; the fixed suffix is only shown on the first row.
+func (r *Render) getSuffix(doc *Document, row Row) StyledText {
 	if suffix, ok := r.suffixCallback(doc, row); ok {
 		return suffix
 	}
-	return r.suffix
+	if row == 0 {
+		return r.suffix
+	}
+	return nil
+}
+
+// resetFrame forgets the displayed frame; the cursor is expected at the start of a line,
+// which becomes the prompt's home position.
+func (r *Render) resetFrame() {
+	r.previous = newFrame(r.termWidth)
+	r.previousCursor = Coord{}
+	r.rowsAllocated = 1
+}
+
+// moveCursor moves the cursor between positions relative to the prompt's home position.
+// Rows below the ones used so far are added with line feeds (scrolling the screen if needed).
+func (r *Render) moveCursor(from, to Coord) {
+	if to.Y >= r.rowsAllocated {
+		r.out.CursorDown(int(r.rowsAllocated - 1 - from.Y))
+		r.out.WriteRawStr("\r" + strings.Repeat("\n", int(to.Y-r.rowsAllocated+1)))
+		r.rowsAllocated = to.Y + 1
+		from = Coord{0, to.Y}
+	}
+	r.out.CursorDown(int(to.Y - from.Y))
+	if to.X == 0 && from.X != 0 {
+		r.out.WriteRawStr("\r")
+	} else {
+		r.out.CursorForward(int(to.X - from.X))
+	}
+}
+
+// paint updates the screen from the previous frame to 'f', writing only the cells that changed,
+// and leaves the cursor at f.cursor.
+func (r *Render) paint(f *frame) {
+	if r.syncOutput {
+		r.out.WriteRawStr(syncOutputBegin)
+	}
+	r.out.HideCursor()
+
+	cursor := r.previousCursor
+	var style Style
+	styleSet := false
+	setStyle := func(s Style) {
+		if !styleSet || s != style {
+			r.setStyle(s)
+			style, styleSet = s, true
+		}
+	}
+
+	rows := len(f.rows)
+	if len(r.previous.rows) > rows {
+		rows = len(r.previous.rows)
+	}
+	for y := 0; y < rows; y++ {
+		var old, new []cell
+		if y < len(r.previous.rows) {
+			old = r.previous.rows[y]
+		}
+		if y < len(f.rows) {
+			new = f.rows[y]
+		}
+		first, last := changedCells(old, new, f.width)
+		if first < 0 {
+			continue
+		}
+		// trailing blanks are erased instead of written
+		end := Column(len(new))
+		for end > 0 && new[end-1] == blankCell {
+			end--
+		}
+		if end > last+1 {
+			end = last + 1
+		}
+
+		x := first
+		if x > end {
+			x = end
+		}
+		r.moveCursor(cursor, Coord{x, Row(y)})
+		cursor = Coord{x, Row(y)}
+		for ; x < end; x++ {
+			c := new[x]
+			if c.r == 0 {
+				continue // covered by the wide character on its left
+			}
+			setStyle(c.style)
+			r.out.WriteRawStr(string(c.r))
+			cursor.X += Column(runewidth.RuneWidth(c.r))
+		}
+		if end <= last {
+			setStyle(defaultStyle)
+			r.out.EraseEndOfLine()
+		}
+		if cursor.X >= f.width {
+			// the cursor stays on the last column until something else is written
+			cursor = r.afterLastColumn(cursor)
+		}
+	}
+
+	setStyle(defaultStyle)
+	r.moveCursor(cursor, f.cursor)
+	r.out.ShowCursor()
+	if r.syncOutput {
+		r.out.WriteRawStr(syncOutputEnd)
+	}
+
+	r.previous = f
+	r.previousCursor = f.cursor
 }
 
-// move moves the cursor in the 'rel' direction (right & down).
-//   if 'rel' values are negative it moves in the oppositve direction
-// returns 'from' + 'rel'
-func (r *Render) move(from, rel Coord) Coord {
-	r.out.CursorDown(int(rel.Y))
-	r.out.CursorForward(int(rel.X))
-	return from.Add(rel)
+// setStyle sets the style of the text written next, with its colors converted to the color depth.
+// Underline styles and colors are only used by terminals with 24-bit colors (and styledUnderlines);
+// others get a plain underline, as they may not parse the sub-parameters (e.g. "4:3").
+func (r *Render) setStyle(s Style) {
+	s.Fg = r.colorDepth.Convert(s.Fg)
+	s.Bg = r.colorDepth.Convert(s.Bg)
+	if r.styledUnderlines && r.colorDepth == ColorDepthTrueColor {
+		s.UnderlineColor = r.colorDepth.Convert(s.UnderlineColor)
+	} else {
+		s.UnderlineStyle = UnderlineSingle
+		s.UnderlineColor = nil
+	}
+	r.out.SetStyle(s)
+	if s.link != r.link {
+		r.out.SetHyperlink(s.link)
+		r.link = s.link
+	}
 }
 
-func (r *Render) promptHome(from Coord) {
-	r.move(Coord{}, Coord{-from.X, -from.Y})
+// changeStyle changes the style of 'role' in the theme.
+func (r *Render) changeStyle(role StyleRole, change func(s *Style)) {
+	s := r.theme.Style(role)
+	change(&s)
+	r.theme = r.theme.With(role, s)
 }
 
-func (r *Render) lineWrap(cursor Column) bool {
-	if runtime.GOOS != "windows" && cursor > 0 && cursor%r.termWidth == 0 {
-		r.out.WriteRaw([]byte{'\n'})
-		return true
+// Theme returns the theme, with the changes made by the color options.
+func (r *Render) Theme() Theme {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+	return r.theme
+}
+
+// SetTheme changes the theme; it's used from the next rendering.
+func (r *Render) SetTheme(t Theme) {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+	r.theme = t
+}
+
+// afterLastColumn returns where the cursor is after writing the last column of a row.
+func (r *Render) afterLastColumn(cursor Coord) Coord {
+	if runtime.GOOS == "windows" {
+		// the console moves the cursor to the next line right away
+		if cursor.Y+1 >= r.rowsAllocated {
+			r.rowsAllocated = cursor.Y + 2
+		}
+		return Coord{0, cursor.Y + 1}
+	}
+	return Coord{cursor.X - 1, cursor.Y}
+}
+
+// changedCells returns the range of cells that differ between two rows (either may be nil, i.e. blank),
+// extended to whole wide characters; 'first' is -1 if the rows are the same.
+func changedCells(old, new []cell, width Column) (first, last Column) {
+	at := func(row []cell, x Column) cell {
+		if x < Column(len(row)) {
+			return row[x]
+		}
+		return blankCell
+	}
+	first, last = -1, -1
+	for x := Column(0); x < width || x < Column(len(old)); x++ {
+		if at(old, x) != at(new, x) {
+			if first < 0 {
+				first = x
+			}
+			last = x
+		}
+	}
+	if first < 0 {
+		return
 	}
-	return false
+	for first > 0 && at(new, first).r == 0 {
+		first--
+	}
+	for last+1 < Column(len(new)) && at(new, last+1).r == 0 {
+		last++
+	}
+	return
+}
+
+// ClearScreen erases the screen, and renders the prompt at the top (on the next Render).
+func (r *Render) ClearScreen() {
+	r.outputLock.Lock()
+	defer r.outputLock.Unlock()
+
+	r.out.EraseScreen()
+	r.out.CursorGoTo(0, 0)
+	debug.AssertNoError(r.out.Flush())
+	r.resetFrame()
 }
 
 func clamp(high, low, x float64) float64 {
@@ -460,6 +779,9 @@ func clamp(high, low, x float64) float64 {
 func (r *Render) renderWindowTooSmall() {
 	r.out.CursorGoTo(0, 0)
 	r.out.EraseScreen()
-	r.out.SetColor(Red, White, false)
-	r.out.WriteStr("Your console window is too small...")
+	r.resetFrame()
+
+	f := newFrame(r.termWidth)
+	f.write(Coord{}, "Your console window is too small...", r.theme.Style(RoleWarning))
+	r.paint(f)
 }
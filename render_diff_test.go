@@ -0,0 +1,147 @@
+package prompt_test
+
+import (
+	"bytes"
+	"testing"
+
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/prompttest"
+)
+
+// countingWriter counts what's written to a Screen.
+type countingWriter struct {
+	*prompttest.Screen
+	n int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n += len(b)
+	return w.Screen.Write(b)
+}
+
+type renderStep struct {
+	text     string
+	cursor   int // from the end of the text
+	complete bool
+	next     int
+}
+
+func (s renderStep) state() (*prompt.Buffer, *prompt.CompletionManager) {
+	buf := prompt.NewBuffer()
+	buf.InsertText(s.text, false, true)
+	buf.CursorPrev(s.cursor)
+	compMgr := prompt.NewCompletionManager(goldenCompleter, 6)
+	if s.complete {
+		compMgr.FindCompletions(*buf.Document())
+		for i := 0; i < s.next; i++ {
+			compMgr.Next()
+		}
+	}
+	return buf, compMgr
+}
+
+// TestRenderDiff renders a sequence of states, and checks that after each one
+// the screen is the same as if only that state was rendered.
+func TestRenderDiff(t *testing.T) {
+	const cols, rows = 40, 12
+	steps := []renderStep{
+		{text: ""},
+		{text: "s"},
+		{text: "se", complete: true},
+		{text: "se", complete: true, next: 1},
+		{text: "se", complete: true, next: 2},
+		{text: "set"},
+		{text: "set abcdefghijklmnopqrstuvwxyz0123456789"},
+		{text: "set abcdefghijklmnopqrstuvwxyz0123456789", cursor: 20},
+		{text: "set abcdefghijklmnopqrstuvwxyz01234567890"},
+		{text: "set abcdefghijklmnopqrstuvwxyz012345"},
+		{text: "s", complete: true},
+		{text: "se", complete: true, next: 3},
+		{text: "set 日本語のテキストを入力する日本語のテキストを入力"},
+		{text: "set 日本語"},
+		{text: "a line that wraps around\nand another one that wraps\nand one more"},
+		{text: "a line that wraps around\nand another one that wraps\nand one more", cursor: 40},
+		{text: "a line that wraps\nand another one that wraps\nand one more"},
+		{text: "a line that wraps\nand another one"},
+		{text: "a\nb\nc"},
+		{text: "a\nb"},
+		{text: "", complete: true},
+		{text: ""},
+	}
+
+	scr := prompttest.NewScreen(cols, rows)
+	r := prompt.NewRender("> ", prompt.NewStreamWriter(scr))
+	r.UpdateWinSize(&prompt.WinSize{Col: cols, Row: rows})
+
+	for i, s := range steps {
+		buf, compMgr := s.state()
+		r.Render(buf, compMgr)
+
+		expected := prompttest.NewScreen(cols, rows)
+		fresh := prompt.NewRender("> ", prompt.NewStreamWriter(expected))
+		fresh.UpdateWinSize(&prompt.WinSize{Col: cols, Row: rows})
+		fresh.Render(s.state())
+
+		if actual, expected := scr.ANSISnapshot(), expected.ANSISnapshot(); actual != expected {
+			t.Errorf("step %d (%#v): Should be\n%s\nbut got\n%s", i, s.text, expected, actual)
+		}
+		x, y := scr.Cursor()
+		ex, ey := expected.Cursor()
+		if x != ex || y != ey {
+			t.Errorf("step %d (%#v): Should be (%d, %d), but got (%d, %d)", i, s.text, ex, ey, x, y)
+		}
+	}
+}
+
+func TestRenderDiffMinimal(t *testing.T) {
+	scr := &countingWriter{Screen: prompttest.NewScreen(80, 24)}
+	r := prompt.NewRender("> ", prompt.NewStreamWriter(scr))
+	r.UpdateWinSize(&prompt.WinSize{Col: 80, Row: 24})
+
+	text := "a long line of text that's rendered once"
+	buf, compMgr := renderStep{text: text}.state()
+	r.Render(buf, compMgr)
+	full := scr.n
+
+	scr.n = 0
+	buf, compMgr = renderStep{text: text + "!"}.state()
+	r.Render(buf, compMgr)
+
+	if scr.n >= full/2 {
+		t.Errorf("Should write less than %d bytes, but got %d", full/2, scr.n)
+	}
+	if line := scr.Line(0); line != "> "+text+"!" {
+		t.Errorf("Should be %#v, but got %#v", "> "+text+"!", line)
+	}
+}
+
+func TestRenderBreakLine(t *testing.T) {
+	var out bytes.Buffer
+	scr := prompttest.NewScreen(30, 10)
+	r := prompt.NewRender("> ", prompt.NewStreamWriter(&multiWriter{scr, &out}))
+	r.UpdateWinSize(&prompt.WinSize{Col: 30, Row: 10})
+
+	buf, compMgr := renderStep{text: "se", complete: true}.state()
+	r.Render(buf, compMgr)
+	r.BreakLine(buf, false)
+	scr.Write([]byte("output\n"))
+	buf, compMgr = renderStep{text: "x"}.state()
+	r.Render(buf, compMgr)
+
+	expected := "> se\noutput\n> x"
+	if actual := scr.Snapshot(); actual != expected {
+		t.Errorf("Should be %#v, but got %#v", expected, actual)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("\x1b[?2026h")) {
+		t.Errorf("Should use synchronized output")
+	}
+}
+
+type multiWriter struct {
+	a, b interface{ Write([]byte) (int, error) }
+}
+
+func (w *multiWriter) Write(p []byte) (int, error) {
+	w.a.Write(p)
+	return w.b.Write(p)
+}
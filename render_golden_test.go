@@ -0,0 +1,379 @@
+package prompt_test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/prompttest"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func goldenCompleter(d prompt.Document) []prompt.Choice {
+	choices := []prompt.Choice{
+		{Text: "select", Description: "Select rows"},
+		{Text: "set", Description: "Set a variable"},
+		{Text: "show", Description: "Show tables"},
+		{Text: "update", Description: "Update rows"},
+	}
+	return prompt.FilterHasPrefix(choices, d.GetWordBeforeCursor(), true)
+}
+
+// linkCompleter is goldenCompleter with descriptions that link to documentation.
+func linkCompleter(d prompt.Document) []prompt.Choice {
+	choices := goldenCompleter(d)
+	for i, c := range choices {
+		choices[i].Description = prompt.Hyperlink("https://example.com/docs/"+c.Text, c.Description)
+	}
+	return choices
+}
+
+// manyCompleter returns more choices than the completion menu shows, so it has a scrollbar.
+func manyCompleter(d prompt.Document) []prompt.Choice {
+	var choices []prompt.Choice
+	for i := 0; i < 10; i++ {
+		choices = append(choices, prompt.Choice{Text: fmt.Sprintf("item%d", i), Description: fmt.Sprintf("Item %d", i)})
+	}
+	return prompt.FilterHasPrefix(choices, d.GetWordBeforeCursor(), true)
+}
+
+// checkGolden compares 'actual' with the golden file testdata/render/'name',
+// or writes it with -update.
+func checkGolden(t *testing.T, name, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", "render", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if actual != string(expected) {
+		t.Errorf("%s: Should be\n%s\nbut got\n%s", path, expected, actual)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	scenarioTable := []struct {
+		name       string
+		cols, rows int
+		text       string
+		cursor     int // from the end of the text
+		complete   bool
+		next       int // times to select the next choice
+	}{
+		{name: "empty", cols: 40, rows: 8},
+		{name: "text", cols: 40, rows: 8, text: "hello world"},
+		{name: "cursor", cols: 40, rows: 8, text: "hello world", cursor: 6},
+		{name: "completion", cols: 40, rows: 8, text: "se", complete: true},
+		{name: "completion-selected", cols: 40, rows: 8, text: "se", complete: true, next: 2},
+		{name: "completion-all", cols: 40, rows: 8, text: "", complete: true},
+		{name: "completion-narrow", cols: 20, rows: 8, text: "se", complete: true},
+		{name: "wrap", cols: 20, rows: 8, text: "abcdefghijklmnopqrstuvwxyz"},
+		{name: "wrap-exact", cols: 20, rows: 8, text: "abcdefghijklmnopqr"},
+		{name: "wide", cols: 20, rows: 8, text: "日本語のテキストを入力する"},
+		{name: "wide-wrap", cols: 20, rows: 8, text: "a日本語のテキストを入力する"},
+		{name: "wrap-long", cols: 20, rows: 8, text: strings.Repeat("0123456789", 7), cursor: 35},
+		{name: "multiline-wrap", cols: 20, rows: 10, text: "a line that wraps around\nand another one that wraps"},
+		{name: "multiline", cols: 40, rows: 10, text: "line one\nline two"},
+		{name: "too-small", cols: 40, rows: 2, text: "se", complete: true},
+	}
+
+	for _, s := range scenarioTable {
+		scr := prompttest.NewScreen(s.cols, s.rows)
+		r := prompt.NewRender("> ", prompt.NewStreamWriter(scr))
+		r.UpdateWinSize(&prompt.WinSize{Col: uint16(s.cols), Row: uint16(s.rows)})
+
+		buf := prompt.NewBuffer()
+		buf.InsertText(s.text, false, true)
+		buf.CursorPrev(s.cursor)
+
+		compMgr := prompt.NewCompletionManager(goldenCompleter, 6)
+		if s.complete {
+			compMgr.FindCompletions(*buf.Document())
+			for i := 0; i < s.next; i++ {
+				compMgr.Next()
+			}
+		}
+
+		r.Render(buf, compMgr)
+
+		x, y := scr.Cursor()
+		checkGolden(t, s.name+".txt", fmt.Sprintf("%s\n-- cursor %d,%d --\n", scr.Snapshot(), x, y))
+		checkGolden(t, s.name+".ansi", scr.ANSISnapshot()+"\n")
+	}
+}
+
+// TestPromptGolden runs prompts on a headless terminal, for the rendering that depends on the prompt's options.
+func TestPromptGolden(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	long := strings.Join(lines, "\n")
+	viewport := []prompt.Option{prompt.OptionLineNumbers(true), prompt.OptionScrollIndicators(true)}
+	suffix := []prompt.Option{prompt.OptionSuffix("[main]")}
+	lineSuffix := []prompt.Option{prompt.OptionLiveSuffix(func(d *prompt.Document, row prompt.Row) (string, bool) {
+		return fmt.Sprintf("(%d)", row+1), true
+	})}
+	powerline := []prompt.Option{
+		prompt.OptionStyledPrefix(prompt.StyledText{
+			{Text: " ~/src ", Style: prompt.Style{Fg: prompt.White, Bg: prompt.Blue, Bold: true}},
+			{Text: "\ue0b0", Style: prompt.Style{Fg: prompt.Blue}},
+			{Text: " "},
+		}),
+		prompt.OptionStyledSuffix(prompt.StyledText{
+			{Text: "\ue0b2", Style: prompt.Style{Fg: prompt.Green}},
+			{Text: " main ", Style: prompt.Style{Fg: prompt.Black, Bg: prompt.Green}},
+		}),
+		prompt.OptionPrefixTextColor(prompt.Yellow),
+	}
+	transient := append([]prompt.Option{
+		prompt.OptionTransientPrompt(func(d *prompt.Document, cancelled bool) (prompt.Transient, bool) {
+			if cancelled {
+				return prompt.Transient{}, false
+			}
+			return prompt.Transient{
+				Prefix:             prompt.StyledText{{Text: "$ ", Style: prompt.Style{Fg: prompt.Green}}},
+				ContinuationPrefix: prompt.StyledText{{Text: "  "}},
+				Style:              prompt.Style{Bold: true},
+			}, true
+		}),
+	}, powerline...)
+	rgb := []prompt.Option{
+		prompt.OptionPrefixTextColor(prompt.NewRGB(0xff, 0x87, 0x00)),
+		prompt.OptionChoiceBGColor(prompt.NewRGB(0x30, 0x30, 0x30)),
+		prompt.OptionChoiceTextColor(prompt.Ansi256Color(153)),
+	}
+	toolbar := []prompt.Option{prompt.OptionToolbar(func(d *prompt.Document, s prompt.ToolbarState) prompt.StyledText {
+		if d.Text() == "hide" {
+			return nil
+		}
+		return prompt.StyledText{
+			{Text: " " + string(s.EditMode) + " ", Style: prompt.Style{Fg: prompt.Black, Bg: prompt.Green, Bold: true}},
+			{Text: fmt.Sprintf(" completing: %v, %d chars", s.Completing, len(d.Text()))},
+		}
+	})}
+
+	keepPartialLine := []prompt.Option{prompt.OptionKeepPartialLine(prompt.DefaultPartialLineMarker)}
+
+	scenarioTable := []struct {
+		name       string
+		cols, rows int
+		opts       []prompt.Option
+		text       string
+		keys       []prompt.KeyCode
+		wait       string           // shown when the input has been handled
+		completer  prompt.Completer // goldenCompleter if nil
+		before     string           // written to the screen before the prompt starts
+	}{
+		{name: "viewport-end", cols: 30, rows: 6, opts: viewport, text: long, wait: "line 20"},
+		{name: "viewport-middle", cols: 30, rows: 6, opts: viewport, text: long, keys: repeatKey(prompt.KeyUp, 10), wait: "10 line 10"},
+		{name: "viewport-top", cols: 30, rows: 6, opts: viewport, text: long, keys: repeatKey(prompt.KeyUp, 19), wait: " 1 > line 1"},
+		{name: "viewport-completion", cols: 40, rows: 8, text: long + "\nset s", wait: "Set a variable"},
+		{name: "toolbar", cols: 40, rows: 8, opts: toolbar, text: "abc", wait: "3 chars"},
+		{name: "toolbar-completion", cols: 40, rows: 8, opts: toolbar, text: "se", wait: "true, 2 chars"},
+		{name: "toolbar-viewport", cols: 30, rows: 6, opts: append(toolbar, viewport...), text: long, wait: "20 line 20"},
+		{name: "toolbar-clipped", cols: 20, rows: 6, opts: toolbar, text: "abc", wait: "> abc"},
+		{name: "suffix", cols: 30, rows: 6, opts: suffix, text: "abc", wait: "> abc"},
+		{name: "suffix-hidden", cols: 20, rows: 6, opts: suffix, text: "abcdefghijkl", wait: "> abcdefghijkl"},
+		{name: "suffix-fits", cols: 20, rows: 6, opts: suffix, text: "abcdefghi", wait: "> abcdefghi"},
+		{name: "suffix-lines", cols: 30, rows: 6, opts: lineSuffix, text: "one\ntwo that is long enough\nthree", wait: "three"},
+		{name: "suffix-accepted", cols: 30, rows: 6, opts: suffix, text: "abc", keys: []prompt.KeyCode{prompt.KeyEnter, prompt.KeyUp}, wait: "> abc\n> abc"},
+		{name: "styled-prefix", cols: 30, rows: 6, opts: powerline, text: "abc", wait: "abc"},
+		{name: "styled-prefix-wrap", cols: 20, rows: 6, opts: powerline, text: "abcdefghijklmnop", wait: "mnop"},
+		{name: "transient", cols: 30, rows: 6, opts: transient, text: "abc", keys: []prompt.KeyCode{prompt.KeyEnter, prompt.KeyUp}, wait: "$ abc\n ~/src"},
+		{name: "transient-multiline", cols: 30, rows: 8, opts: transient, text: "abc\ndef", keys: []prompt.KeyCode{prompt.KeyEnter}, wait: "  def\n ~/src"},
+		{name: "transient-cancelled", cols: 30, rows: 6, opts: transient, text: "abc", keys: []prompt.KeyCode{prompt.KeyControl | prompt.KeyC}, wait: "abc\n ~/src"},
+		{name: "theme-light", cols: 40, rows: 8, opts: []prompt.Option{prompt.OptionTheme(prompt.LightTheme)}, text: "se", keys: []prompt.KeyCode{prompt.KeyTab}, wait: "> select"},
+		{name: "theme-attributes", cols: 40, rows: 8, opts: []prompt.Option{prompt.OptionTheme(prompt.DarkTheme.
+			With(prompt.RolePrefix, prompt.Style{Fg: prompt.Cyan, Italic: true}).
+			With(prompt.RoleInput, prompt.Style{Underline: true}))}, text: "abc", wait: "> abc"},
+		{name: "theme-full-attributes", cols: 40, rows: 8, opts: []prompt.Option{prompt.OptionTheme(prompt.DarkTheme.
+			With(prompt.RolePreviewChoice, prompt.Style{Underline: true, UnderlineStyle: prompt.UnderlineCurly, UnderlineColor: prompt.Red}).
+			With(prompt.RoleSelectedChoice, prompt.Style{Reverse: true, Bold: true}).
+			With(prompt.RoleDescription, prompt.Style{Faint: true, Italic: true}))},
+			text: "se", keys: []prompt.KeyCode{prompt.KeyTab}, wait: "> select"},
+		{name: "color-truecolor", cols: 40, rows: 8, opts: rgb, text: "se", wait: "Set a variable"},
+		{name: "color-256", cols: 40, rows: 8, opts: append(rgb, prompt.OptionColorDepth(prompt.ColorDepth256)), text: "se", wait: "Set a variable"},
+		{name: "color-16", cols: 40, rows: 8, opts: append(rgb, prompt.OptionColorDepth(prompt.ColorDepth16)), text: "se", wait: "Set a variable"},
+		{name: "color-none", cols: 40, rows: 8, opts: append(rgb, prompt.OptionColorDepth(prompt.ColorDepthNone)), text: "se", wait: "Set a variable"},
+		{name: "toolbar-hidden", cols: 20, rows: 6, opts: toolbar, text: "hide", wait: "> hide"},
+		{name: "hyperlink-description", cols: 40, rows: 8, text: "se", wait: "Set a variable", completer: linkCompleter},
+		{name: "hyperlink-truncated", cols: 32, rows: 8, text: "se", wait: "Set a var…", completer: linkCompleter},
+		{name: "completion-scrollbar", cols: 40, rows: 10, text: "i", wait: "Item 5", completer: manyCompleter},
+		{name: "completion-scrollbar-end", cols: 40, rows: 10, text: "i", keys: repeatKey(prompt.KeyTab, 10), wait: "> item9", completer: manyCompleter},
+		{name: "probe-partial-line", cols: 30, rows: 6, opts: []prompt.Option{prompt.OptionProbeTerminal(true)}, before: "$ printf partial", text: "abc", wait: "> abc"},
+		{name: "probe-new-line", cols: 30, rows: 6, opts: []prompt.Option{prompt.OptionProbeTerminal(true)}, before: "$ echo line\n", text: "abc", wait: "> abc"},
+		{name: "partial-line-marker", cols: 30, rows: 6, opts: keepPartialLine, before: "$ printf partial", text: "abc", wait: "> abc"},
+		{name: "partial-line-no-marker", cols: 30, rows: 6, opts: []prompt.Option{prompt.OptionKeepPartialLine(nil)}, before: "$ printf partial", text: "abc", wait: "> abc"},
+		{name: "partial-line-full", cols: 16, rows: 6, opts: keepPartialLine, before: "$ printf partial", text: "abc", wait: "> abc"},
+	}
+
+	for _, s := range scenarioTable {
+		term := prompttest.NewTerminal(s.cols, s.rows)
+		term.Screen.Write([]byte(s.before))
+		completer := s.completer
+		if completer == nil {
+			completer = goldenCompleter
+		}
+		p := prompt.New(func(string) {}, completer, append(term.Options(), s.opts...)...)
+		stop := startPrompt(t, p)
+
+		term.Type(s.text)
+		term.Press(s.keys...)
+		if !term.Screen.WaitForText(s.wait, time.Second) {
+			t.Errorf("%s: Should show %#v, but got\n%s", s.name, s.wait, term.Screen.Snapshot())
+		}
+		x, y := term.Screen.Cursor()
+		checkGolden(t, s.name+".txt", fmt.Sprintf("%s\n-- cursor %d,%d --\n", term.Screen.Snapshot(), x, y))
+		checkGolden(t, s.name+".ansi", term.Screen.ANSISnapshot()+"\n")
+
+		stop()
+	}
+}
+
+// startPrompt runs 'p' until 'stop' is called, or the test ends.
+func startPrompt(t *testing.T, p *prompt.Prompt) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.RunContext(ctx)
+	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func repeatKey(k prompt.KeyCode, n int) []prompt.KeyCode {
+	keys := make([]prompt.KeyCode, n)
+	for i := range keys {
+		keys[i] = k
+	}
+	return keys
+}
+
+func TestSetTheme(t *testing.T) {
+	term := prompttest.NewTerminal(40, 8)
+	p := prompt.New(func(string) {}, goldenCompleter, term.Options()...)
+	startPrompt(t, p)
+
+	term.Type("abc")
+	if !term.Screen.WaitForText("> abc", time.Second) {
+		t.Fatalf("Should show %#v, but got\n%s", "> abc", term.Screen.Snapshot())
+	}
+	p.SetTheme(prompt.DarkTheme.With(prompt.RoleInput, prompt.Style{Fg: prompt.Green}))
+	if !term.Screen.WaitFor(time.Second, func(s *prompttest.Screen) bool {
+		return s.Cell(2, 0).Style.Fg == prompt.Green
+	}) {
+		t.Errorf("Should be rendered again, but got\n%s", term.Screen.ANSISnapshot())
+	}
+}
+
+func TestOutputAsyncHyperlink(t *testing.T) {
+	term := prompttest.NewTerminal(40, 8)
+	p := prompt.New(func(string) {}, goldenCompleter, term.Options()...)
+	startPrompt(t, p)
+
+	term.Type("abc")
+	if !term.Screen.WaitForText("> abc", time.Second) {
+		t.Fatalf("Should show %#v, but got\n%s", "> abc", term.Screen.Snapshot())
+	}
+	// the link isn't ended, it mustn't run into the prompt
+	p.OutputAsync("see \x1b]8;;https://example.com\x1b\\the docs")
+	if !term.Screen.WaitForText("see the docs\n> abc", time.Second) {
+		t.Fatalf("Should show %#v, but got\n%s", "see the docs\n> abc", term.Screen.Snapshot())
+	}
+	scenarioTable := []struct {
+		x, y     int
+		expected string
+	}{
+		{x: 0, y: 0, expected: ""},
+		{x: 4, y: 0, expected: "https://example.com"},
+		{x: 2, y: 1, expected: ""},
+	}
+	for _, s := range scenarioTable {
+		if link := term.Screen.Cell(s.x, s.y).Link; link != s.expected {
+			t.Errorf("Should be %#v, but got %#v", s.expected, link)
+		}
+	}
+}
+
+func TestProbeTerminal(t *testing.T) {
+	term := prompttest.NewTerminal(40, 8)
+	term.Screen.Write([]byte("abc"))
+	infoCh := make(chan prompt.TerminalInfo, 1)
+	var p *prompt.Prompt
+	p = prompt.New(func(string) { infoCh <- p.TerminalInfo() }, goldenCompleter,
+		append(term.Options(), prompt.OptionProbeTerminal(true))...)
+	startPrompt(t, p)
+
+	if !term.Screen.WaitForText(">", time.Second) {
+		t.Fatalf("Should show %#v, but got\n%s", ">", term.Screen.Snapshot())
+	}
+	term.Press(prompt.KeyEnter)
+	select {
+	case info := <-infoCh:
+		expected := prompt.TerminalInfo{
+			Cursor:      prompt.Coord{X: 3},
+			CursorKnown: true,
+			Responded:   true,
+			Attributes:  []int{prompt.DeviceAttributeANSIColor},
+			Version:     prompttest.Version,
+		}
+		if !reflect.DeepEqual(info, expected) {
+			t.Errorf("Should be %#v, but got %#v", expected, info)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Should execute the input, but got\n%s", term.Screen.Snapshot())
+	}
+}
+
+func TestProbeTerminalTimeout(t *testing.T) {
+	term := prompttest.NewTerminal(40, 8)
+	term.Screen.OnResponse = nil // a terminal that doesn't answer
+	p := prompt.New(func(string) {}, goldenCompleter, append(term.Options(), prompt.OptionProbeTerminal(true))...)
+	startPrompt(t, p)
+
+	term.Type("abc")
+	if !term.Screen.WaitForText("> abc", time.Second) {
+		t.Errorf("Should show %#v, but got\n%s", "> abc", term.Screen.Snapshot())
+	}
+}
+
+func TestKeepPartialLineAfterExecute(t *testing.T) {
+	term := prompttest.NewTerminal(30, 6)
+	p := prompt.New(func(s string) { term.Screen.Write([]byte("output of " + s)) }, goldenCompleter,
+		append(term.Options(), prompt.OptionKeepPartialLine(prompt.DefaultPartialLineMarker))...)
+	startPrompt(t, p)
+
+	if !term.Screen.WaitForText(">", time.Second) {
+		t.Fatalf("Should show %#v, but got\n%s", ">", term.Screen.Snapshot())
+	}
+	term.Type("abc")
+	term.Press(prompt.KeyEnter)
+	term.Type("def")
+	if expected := "> abc\noutput of abc%\n> def"; !term.Screen.WaitForText(expected, time.Second) {
+		t.Errorf("Should show %#v, but got\n%s", expected, term.Screen.Snapshot())
+	}
+}
@@ -8,16 +8,16 @@ import (
 func TestFormatCompletion(t *testing.T) {
 	scenarioTable := []struct {
 		scenario      string
-		completions   []Suggest
+		completions   []Choice
 		prefix        string
 		suffix        string
-		expected      []Suggest
-		maxWidth      int
-		expectedWidth int
+		expected      []Choice
+		maxWidth      Column
+		expectedWidth Column
 	}{
 		{
 			scenario: "",
-			completions: []Suggest{
+			completions: []Choice{
 				{Text: "select"},
 				{Text: "from"},
 				{Text: "insert"},
@@ -25,7 +25,7 @@ func TestFormatCompletion(t *testing.T) {
 			},
 			prefix: " ",
 			suffix: " ",
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: " select "},
 				{Text: " from   "},
 				{Text: " insert "},
@@ -36,7 +36,7 @@ func TestFormatCompletion(t *testing.T) {
 		},
 		{
 			scenario: "",
-			completions: []Suggest{
+			completions: []Choice{
 				{Text: "select", Description: "select description"},
 				{Text: "from", Description: "from description"},
 				{Text: "insert", Description: "insert description"},
@@ -44,7 +44,7 @@ func TestFormatCompletion(t *testing.T) {
 			},
 			prefix: " ",
 			suffix: " ",
-			expected: []Suggest{
+			expected: []Choice{
 				{Text: " select ", Description: " select description "},
 				{Text: " from   ", Description: " from description   "},
 				{Text: " insert ", Description: " insert description "},
@@ -56,7 +56,7 @@ func TestFormatCompletion(t *testing.T) {
 	}
 
 	for _, s := range scenarioTable {
-		ac, width := formatSuggestions(s.completions, s.maxWidth)
+		ac, width := formatChoices(s.completions, s.maxWidth)
 		if !reflect.DeepEqual(ac, s.expected) {
 			t.Errorf("Should be %#v, but got %#v", s.expected, ac)
 		}
@@ -0,0 +1,170 @@
+package prompt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type castEvent struct {
+	time float64
+	kind string
+	data string
+}
+
+// ReplayParser is a CancelableParser returning the input of a session recorded with OptionRecord.
+// Recorded size changes are returned by GetWinSize, and signaled (see WinSizeNotifier), as they are reached.
+type ReplayParser struct {
+	mu       sync.Mutex
+	events   []castEvent
+	size     WinSize
+	speed    float64
+	last     float64 // the time of the last input returned
+	done     chan struct{}
+	doneOnce sync.Once
+	cancel   chan struct{}
+	resized  chan struct{}
+}
+
+var (
+	_ CancelableParser = &ReplayParser{}
+	_ WinSizeNotifier  = &ReplayParser{}
+)
+
+// NewReplayParser reads a recording. The input is returned with the recorded delays divided by 'speed',
+// or without delays if 'speed' is 0.
+func NewReplayParser(r io.Reader, speed float64) (*ReplayParser, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("recording: missing header")
+	}
+	var header castHeader
+	if err := json.Unmarshal(sc.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("recording: header: %w", err)
+	}
+	if header.Version != 2 {
+		return nil, fmt.Errorf("recording: unsupported version %d", header.Version)
+	}
+
+	p := &ReplayParser{
+		size:    WinSize{Col: uint16(header.Width), Row: uint16(header.Height)},
+		speed:   speed,
+		done:    make(chan struct{}),
+		cancel:  make(chan struct{}, 1),
+		resized: make(chan struct{}, 1),
+	}
+	for line := 2; sc.Scan(); line++ {
+		var ev []json.RawMessage
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || len(ev) != 3 {
+			return nil, fmt.Errorf("recording:%d: invalid event", line)
+		}
+		var e castEvent
+		if json.Unmarshal(ev[0], &e.time) != nil || json.Unmarshal(ev[1], &e.kind) != nil || json.Unmarshal(ev[2], &e.data) != nil {
+			return nil, fmt.Errorf("recording:%d: invalid event", line)
+		}
+		if e.kind == "r" {
+			var ws WinSize
+			if _, err := fmt.Sscanf(e.data, "%dx%d", &ws.Col, &ws.Row); err != nil {
+				return nil, fmt.Errorf("recording:%d: invalid size %q", line, e.data)
+			}
+		}
+		p.events = append(p.events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Done returns a channel that's closed when all the input has been returned.
+func (p *ReplayParser) Done() <-chan struct{} {
+	return p.done
+}
+
+// Setup does nothing.
+func (p *ReplayParser) Setup() error {
+	return nil
+}
+
+// TearDown does nothing.
+func (p *ReplayParser) TearDown() error {
+	return nil
+}
+
+// WinSizeChanged returns a channel that receives when a recorded size change is reached.
+func (p *ReplayParser) WinSizeChanged() <-chan struct{} {
+	return p.resized
+}
+
+// GetWinSize returns the recorded size.
+func (p *ReplayParser) GetWinSize() *WinSize {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ws := p.size
+	return &ws
+}
+
+// Read returns the next recorded input, after its recorded delay.
+// At the end of the recording it returns io.EOF, which ends the prompt (with ErrEOF)
+// once the input before it has been handled.
+func (p *ReplayParser) Read() ([]byte, error) {
+	for {
+		p.mu.Lock()
+		if len(p.events) == 0 {
+			p.mu.Unlock()
+			p.doneOnce.Do(func() { close(p.done) })
+			return nil, io.EOF
+		}
+		e := p.events[0]
+		if e.kind != "i" {
+			p.events = p.events[1:]
+			if e.kind == "r" {
+				fmt.Sscanf(e.data, "%dx%d", &p.size.Col, &p.size.Row)
+				select {
+				case p.resized <- struct{}{}:
+				default:
+				}
+			}
+			p.mu.Unlock()
+			continue
+		}
+		delay := time.Duration(0)
+		if p.speed > 0 && e.time > p.last {
+			delay = time.Duration((e.time - p.last) / p.speed * float64(time.Second))
+		}
+		p.mu.Unlock()
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-p.cancel:
+				timer.Stop()
+				return nil, ErrReadCanceled
+			}
+		}
+
+		p.mu.Lock()
+		p.events = p.events[1:]
+		p.last = e.time
+		p.mu.Unlock()
+		return []byte(e.data), nil
+	}
+}
+
+// Cancel makes a blocked (or the next) Read return ErrReadCanceled.
+func (p *ReplayParser) Cancel() error {
+	select {
+	case p.cancel <- struct{}{}:
+	default:
+	}
+	return nil
+}
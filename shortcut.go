@@ -5,7 +5,7 @@ func dummyExecutor(in string) { return }
 // Input get the input data from the user and return it.
 func Input(prefix string, completer Completer, opts ...Option) string {
 	pt := New(dummyExecutor, completer)
-	pt.renderer.prefix = prefix
+	pt.renderer.prefix = plainText(prefix)
 
 	for _, opt := range opts {
 		if err := opt(pt); err != nil {
@@ -20,7 +20,7 @@ func Input(prefix string, completer Completer, opts ...Option) string {
 func Choose(prefix string, choices []string, opts ...Option) string {
 	completer := newChoiceCompleter(choices, FilterHasPrefix)
 	pt := New(dummyExecutor, completer)
-	pt.renderer.prefix = prefix
+	pt.renderer.prefix = plainText(prefix)
 
 	for _, opt := range opts {
 		if err := opt(pt); err != nil {
@@ -0,0 +1,77 @@
+package prompt
+
+// Segment is a piece of text with its own style.
+// Nil colors are those of the text around it (e.g. the prefix colors for a prefix);
+// SGR escape sequences in the text change the style from there.
+type Segment struct {
+	Text  string
+	Style Style
+}
+
+// StyledText is text made of segments with their own styles,
+// e.g. for powerline-style prefixes (see OptionStyledPrefix).
+type StyledText []Segment
+
+// plainText returns 's' as a single segment in the style of its surroundings (nil if it's empty).
+func plainText(s string) StyledText {
+	if s == "" {
+		return nil
+	}
+	return StyledText{{Text: s}}
+}
+
+// String returns the text of the segments.
+func (t StyledText) String() string {
+	var s string
+	for _, seg := range t {
+		s += seg.Text
+	}
+	return s
+}
+
+// Width returns the number of columns the text takes on a single row (escape sequences take none).
+func (t StyledText) Width() int {
+	var w Column
+	for _, seg := range t {
+		w += textWidth(seg.Text)
+	}
+	return int(w)
+}
+
+// over returns the style with nil colors taken from 'base', and the attributes of both.
+func (s Style) over(base Style) Style {
+	if s.Fg == nil {
+		s.Fg = base.Fg
+	}
+	if s.Bg == nil {
+		s.Bg = base.Bg
+	}
+	if !s.Underline {
+		s.UnderlineStyle, s.UnderlineColor = base.UnderlineStyle, base.UnderlineColor
+	} else if s.UnderlineColor == nil {
+		s.UnderlineColor = base.UnderlineColor
+	}
+	s.Bold = s.Bold || base.Bold
+	s.Faint = s.Faint || base.Faint
+	s.Italic = s.Italic || base.Italic
+	s.Underline = s.Underline || base.Underline
+	s.Blink = s.Blink || base.Blink
+	s.Reverse = s.Reverse || base.Reverse
+	s.Conceal = s.Conceal || base.Conceal
+	s.CrossedOut = s.CrossedOut || base.CrossedOut
+	return s
+}
+
+// walkStyledText is like walkText, for the segments of 't' with 'base' under their styles.
+func walkStyledText(pos Coord, width Column, t StyledText, base Style, fn func(at Coord, r rune, w Column, style Style)) Coord {
+	for _, seg := range t {
+		pos = walkText(pos, width, seg.Text, seg.Style.over(base), fn)
+	}
+	return pos
+}
+
+// writeStyled writes the segments of 't' at 'pos' like write, with 'base' under their styles.
+func (f *frame) writeStyled(pos Coord, t StyledText, base Style) Coord {
+	f.row(pos.Y)
+	return walkStyledText(pos, f.width, t, base.normalized(), f.put)
+}
@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestStyledTextWidth(t *testing.T) {
+	scenarioTable := []struct {
+		text     StyledText
+		expected int
+	}{
+		{text: nil, expected: 0},
+		{text: StyledText{{Text: "> "}}, expected: 2},
+		{text: StyledText{{Text: " main ", Style: Style{Fg: Black, Bg: Blue}}, {Text: "", Style: Style{Fg: Blue}}, {Text: " "}}, expected: 8},
+		{text: StyledText{{Text: "\x1b[32m~/src\x1b[0m "}}, expected: 6},
+		{text: StyledText{{Text: "日本 "}}, expected: 5},
+	}
+
+	for _, s := range scenarioTable {
+		if w := s.text.Width(); w != s.expected {
+			t.Errorf("%#v: Should be %v, but got %v", s.text.String(), s.expected, w)
+		}
+		// the cursor is placed after the prefix
+		doc := NewDocument("abc", 3)
+		l := layoutText(doc, 80, func(*Document, Row) StyledText { return s.text })
+		if c := l.cursor(3); c != (Coord{Column(s.expected) + 3, 0}) {
+			t.Errorf("%#v: Should be %v, but got %v", s.text.String(), Coord{Column(s.expected) + 3, 0}, c)
+		}
+	}
+}
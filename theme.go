@@ -0,0 +1,281 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StyleRole names a part of the prompt that a Theme gives a style.
+type StyleRole string
+
+// The style roles.
+const (
+	RolePrefix              StyleRole = "prefix"
+	RoleInput               StyleRole = "input"
+	RoleCancelled           StyleRole = "cancelled" // the prefix and text of a cancelled input
+	RolePreviewChoice       StyleRole = "previewChoice"
+	RoleChoice              StyleRole = "choice"
+	RoleDescription         StyleRole = "description"
+	RoleSelectedChoice      StyleRole = "selectedChoice"
+	RoleSelectedDescription StyleRole = "selectedDescription"
+	RoleScrollbarThumb      StyleRole = "scrollbarThumb"
+	RoleScrollbar           StyleRole = "scrollbar"
+	RoleError               StyleRole = "error"
+	RoleToolbar             StyleRole = "toolbar"
+	RoleStatus              StyleRole = "status" // e.g. a partially typed key sequence
+	RoleLineNumber          StyleRole = "lineNumber"
+	RoleScrollIndicator     StyleRole = "scrollIndicator"
+	RoleWarning             StyleRole = "warning"     // the console window being too small
+	RolePartialLine         StyleRole = "partialLine" // the marker at the end of a partial line (see OptionKeepPartialLine)
+)
+
+// Theme is a set of styles, by role.
+type Theme struct {
+	Name   string
+	Styles map[StyleRole]Style
+}
+
+// DarkTheme is the default theme, for terminals with a dark background.
+var DarkTheme = Theme{
+	Name: "dark",
+	Styles: map[StyleRole]Style{
+		RolePrefix:              {},
+		RoleInput:               {},
+		RoleCancelled:           {Fg: BrightBlack},
+		RolePreviewChoice:       {Fg: White},
+		RoleChoice:              {Fg: Black, Bg: Gray},
+		RoleDescription:         {Fg: BrightBlack},
+		RoleSelectedChoice:      {Fg: White, Bg: Blue, Bold: true},
+		RoleSelectedDescription: {Fg: Gray},
+		RoleScrollbarThumb:      {Bg: BrightBlack},
+		RoleScrollbar:           {},
+		RoleError:               {Fg: Red},
+		RoleToolbar:             {Fg: White, Bg: BrightBlack},
+		RoleStatus:              {Fg: BrightBlack},
+		RoleLineNumber:          {Fg: BrightBlack},
+		RoleScrollIndicator:     {Fg: BrightBlack},
+		RoleWarning:             {Fg: Red, Bg: White},
+		RolePartialLine:         {Bold: true, Reverse: true},
+	},
+}
+
+// LightTheme is a theme for terminals with a light background.
+var LightTheme = Theme{
+	Name: "light",
+	Styles: map[StyleRole]Style{
+		RolePrefix:              {},
+		RoleInput:               {},
+		RoleCancelled:           {Fg: BrightBlack},
+		RolePreviewChoice:       {Fg: Blue},
+		RoleChoice:              {Fg: Black, Bg: Gray},
+		RoleDescription:         {Fg: BrightBlack, Bg: Gray},
+		RoleSelectedChoice:      {Fg: White, Bg: Blue, Bold: true},
+		RoleSelectedDescription: {Fg: White, Bg: Blue},
+		RoleScrollbarThumb:      {Bg: BrightBlack},
+		RoleScrollbar:           {},
+		RoleError:               {Fg: Red},
+		RoleToolbar:             {Fg: Black, Bg: Gray},
+		RoleStatus:              {Fg: BrightBlack},
+		RoleLineNumber:          {Fg: BrightBlack},
+		RoleScrollIndicator:     {Fg: BrightBlack},
+		RoleWarning:             {Fg: Red, Bg: White},
+		RolePartialLine:         {Bold: true, Reverse: true},
+	},
+}
+
+var builtinThemes = map[string]Theme{
+	DarkTheme.Name:  DarkTheme,
+	LightTheme.Name: LightTheme,
+}
+
+// Style returns the style of 'role'; roles the theme doesn't have get the style of DarkTheme.
+func (t Theme) Style(role StyleRole) Style {
+	if s, ok := t.Styles[role]; ok {
+		return s
+	}
+	return DarkTheme.Styles[role]
+}
+
+// With returns a copy of the theme with 'role' set to 'style'.
+func (t Theme) With(role StyleRole, style Style) Theme {
+	t = t.clone()
+	t.Styles[role] = style
+	return t
+}
+
+func (t Theme) clone() Theme {
+	styles := make(map[StyleRole]Style, len(t.Styles)+1)
+	for r, s := range t.Styles {
+		styles[r] = s
+	}
+	t.Styles = styles
+	return t
+}
+
+// A theme file is a JSON object like
+//
+//	{
+//		"name": "solarized",
+//		"base": "light",
+//		"styles": {
+//			"prefix": {"fg": "#268bd2", "bold": true},
+//			"selectedChoice": {"fg": "white", "bg": "blue", "underline": true},
+//			"error": {"underline": true, "underlineStyle": "curly", "underlineColor": "red"}
+//		}
+//	}
+//
+// Roles it doesn't set have the style of the "base" theme ("dark" or "light"; "dark" if it's not given).
+// Colors are "default", the ANSI color names ("black", "red", ..., "gray", "brightBlack", ..., "white")
+// or "#rrggbb". The attributes are "bold", "faint", "italic", "underline", "blink", "reverse", "conceal"
+// and "crossedOut"; underline styles are "single", "double", "curly", "dotted" and "dashed".
+
+type themeFile struct {
+	Name   string                   `json:"name"`
+	Base   string                   `json:"base"`
+	Styles map[StyleRole]styleEntry `json:"styles"`
+}
+
+type styleEntry struct {
+	Fg             string `json:"fg"`
+	Bg             string `json:"bg"`
+	Bold           bool   `json:"bold"`
+	Faint          bool   `json:"faint"`
+	Italic         bool   `json:"italic"`
+	Underline      bool   `json:"underline"`
+	UnderlineStyle string `json:"underlineStyle"`
+	UnderlineColor string `json:"underlineColor"`
+	Blink          bool   `json:"blink"`
+	Reverse        bool   `json:"reverse"`
+	Conceal        bool   `json:"conceal"`
+	CrossedOut     bool   `json:"crossedOut"`
+}
+
+var underlineStyleNames = map[string]UnderlineStyle{
+	"":       UnderlineSingle,
+	"single": UnderlineSingle,
+	"double": UnderlineDouble,
+	"curly":  UnderlineCurly,
+	"dotted": UnderlineDotted,
+	"dashed": UnderlineDashed,
+}
+
+// LoadTheme reads a theme in JSON (see above).
+func LoadTheme(r io.Reader) (Theme, error) {
+	var tf themeFile
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&tf); err != nil {
+		return Theme{}, fmt.Errorf("theme: %w", err)
+	}
+
+	base := DarkTheme
+	if tf.Base != "" {
+		var ok bool
+		if base, ok = builtinThemes[tf.Base]; !ok {
+			return Theme{}, fmt.Errorf("theme: unknown base theme %q", tf.Base)
+		}
+	}
+	t := base.clone()
+	t.Name = tf.Name
+	for role, e := range tf.Styles {
+		if _, ok := DarkTheme.Styles[role]; !ok {
+			return Theme{}, fmt.Errorf("theme: unknown style role %q", role)
+		}
+		s, err := e.style()
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme: %s: %w", role, err)
+		}
+		t.Styles[role] = s
+	}
+	return t, nil
+}
+
+func (e styleEntry) style() (Style, error) {
+	s := Style{
+		Bold:       e.Bold,
+		Faint:      e.Faint,
+		Italic:     e.Italic,
+		Underline:  e.Underline,
+		Blink:      e.Blink,
+		Reverse:    e.Reverse,
+		Conceal:    e.Conceal,
+		CrossedOut: e.CrossedOut,
+	}
+	var ok bool
+	if s.UnderlineStyle, ok = underlineStyleNames[e.UnderlineStyle]; !ok {
+		return Style{}, fmt.Errorf("invalid underline style %q", e.UnderlineStyle)
+	}
+	var err error
+	if s.Fg, err = parseColor(e.Fg); err != nil {
+		return Style{}, err
+	}
+	if s.Bg, err = parseColor(e.Bg); err != nil {
+		return Style{}, err
+	}
+	if s.UnderlineColor, err = parseColor(e.UnderlineColor); err != nil {
+		return Style{}, err
+	}
+	return s, nil
+}
+
+// LoadThemeFile reads a theme from a JSON file (see LoadTheme).
+func LoadThemeFile(path string) (Theme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	defer f.Close()
+	return LoadTheme(f)
+}
+
+var colorNames = map[string]AnsiColor{
+	"default":       DefaultColor,
+	"black":         Black,
+	"red":           Red,
+	"green":         Green,
+	"yellow":        Yellow,
+	"blue":          Blue,
+	"magenta":       Magenta,
+	"cyan":          Cyan,
+	"gray":          Gray,
+	"brightBlack":   BrightBlack,
+	"brightRed":     BrightRed,
+	"brightGreen":   BrightGreen,
+	"brightYellow":  BrightYellow,
+	"brightBlue":    BrightBlue,
+	"brightMagenta": BrightMagenta,
+	"brightCyan":    BrightCyan,
+	"white":         White,
+}
+
+// parseColor parses a color of a theme file; "" is nil (i.e. the color around it).
+func parseColor(s string) (Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if c, ok := colorNames[s]; ok {
+		return c, nil
+	}
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		if v, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return NewRGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid color %q", s)
+}
+
+// Theme returns the theme, with the changes made by the color options (e.g. OptionChoiceBGColor);
+// change it with Theme.With and SetTheme.
+func (p *Prompt) Theme() Theme {
+	return p.renderer.Theme()
+}
+
+// SetTheme changes the theme; a running prompt is rendered again.
+func (p *Prompt) SetTheme(t Theme) {
+	p.renderer.SetTheme(t)
+	p.Invalidate()
+}
@@ -0,0 +1,90 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadTheme(t *testing.T) {
+	theme, err := LoadTheme(strings.NewReader(`{
+		"name": "custom",
+		"base": "light",
+		"styles": {
+			"prefix": {"fg": "#268bd2", "bold": true},
+			"selectedChoice": {"fg": "white", "bg": "brightBlue", "italic": true, "underline": true},
+			"error": {"underline": true, "underlineStyle": "curly", "underlineColor": "red", "crossedOut": true}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Should be nil, but got %v", err)
+	}
+	if theme.Name != "custom" {
+		t.Errorf("Should be %#v, but got %#v", "custom", theme.Name)
+	}
+	scenarioTable := []struct {
+		role     StyleRole
+		expected Style
+	}{
+		{role: RolePrefix, expected: Style{Fg: NewRGB(0x26, 0x8b, 0xd2), Bold: true}},
+		{role: RoleSelectedChoice, expected: Style{Fg: White, Bg: BrightBlue, Italic: true, Underline: true}},
+		{role: RolePreviewChoice, expected: LightTheme.Style(RolePreviewChoice)},
+		{role: RoleError, expected: Style{Underline: true, UnderlineStyle: UnderlineCurly, UnderlineColor: Red, CrossedOut: true}},
+		{role: RoleWarning, expected: Style{Fg: Red, Bg: White}},
+	}
+	for _, s := range scenarioTable {
+		if actual := theme.Style(s.role); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%s: Should be %#v, but got %#v", s.role, s.expected, actual)
+		}
+	}
+	if !reflect.DeepEqual(LightTheme.Style(RolePrefix), Style{}) {
+		t.Errorf("Should not change the base theme, but got %#v", LightTheme.Style(RolePrefix))
+	}
+}
+
+func TestLoadThemeErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"styles": {"prompt": {"fg": "red"}}}`,
+		`{"styles": {"prefix": {"fg": "orange"}}}`,
+		`{"styles": {"prefix": {"bg": "#12345"}}}`,
+		`{"styles": {"prefix": {"underlineStyle": "wavy"}}}`,
+		`{"styles": {"prefix": {"underlineColor": "none"}}}`,
+		`{"base": "solarized"}`,
+		`{"colors": {}}`,
+		`{`,
+	} {
+		if _, err := LoadTheme(strings.NewReader(s)); err == nil {
+			t.Errorf("%s: Should be an error, but got nil", s)
+		}
+	}
+}
+
+func TestThemeWith(t *testing.T) {
+	theme := DarkTheme.With(RoleChoice, Style{Fg: Yellow})
+	if !reflect.DeepEqual(theme.Style(RoleChoice), Style{Fg: Yellow}) {
+		t.Errorf("Should be %#v, but got %#v", Style{Fg: Yellow}, theme.Style(RoleChoice))
+	}
+	if !reflect.DeepEqual(DarkTheme.Style(RoleChoice), Style{Fg: Black, Bg: Gray}) {
+		t.Errorf("Should not change DarkTheme, but got %#v", DarkTheme.Style(RoleChoice))
+	}
+	if !reflect.DeepEqual(theme.Style(RoleSelectedChoice), DarkTheme.Style(RoleSelectedChoice)) {
+		t.Errorf("Should keep the other styles, but got %#v", theme.Style(RoleSelectedChoice))
+	}
+}
+
+func TestThemeOptions(t *testing.T) {
+	p := New(func(string) {}, func(Document) []Choice { return nil },
+		OptionPrefixTextColor(Yellow),
+		OptionTheme(LightTheme),
+		OptionChoiceBGColor(Cyan),
+	)
+	if c := p.Theme().Style(RolePrefix).Fg; c != nil {
+		t.Errorf("Should be replaced by the theme, but got %#v", c)
+	}
+	if s := p.Theme().Style(RoleChoice); !reflect.DeepEqual(s, Style{Fg: Black, Bg: Cyan}) {
+		t.Errorf("Should be %#v, but got %#v", Style{Fg: Black, Bg: Cyan}, s)
+	}
+	if s := LightTheme.Style(RoleChoice); s.Bg != Gray {
+		t.Errorf("Should not change LightTheme, but got %#v", s)
+	}
+}
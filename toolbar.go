@@ -0,0 +1,63 @@
+package prompt
+
+import "time"
+
+// ToolbarState is the state of the prompt given to a Toolbar.
+type ToolbarState struct {
+	EditMode    EditMode
+	Completing  bool   // the completion menu is shown
+	PendingKeys string // a partially typed key sequence (e.g. "C-x"), if any
+	Width       int    // of the terminal
+}
+
+// Toolbar returns the text shown in the toolbar below the input (see OptionToolbar);
+// nil colors in it are the toolbar's colors. The toolbar is hidden while it returns nothing.
+type Toolbar func(doc *Document, state ToolbarState) StyledText
+
+// toolbarState returns the state given to the toolbar.
+func (p *Prompt) toolbarState() ToolbarState {
+	s := ToolbarState{
+		EditMode:   p.editMode,
+		Completing: p.completion.NumChoices() > 0,
+		Width:      int(p.renderer.termWidth),
+	}
+	if len(p.pendingKeys) > 0 {
+		s.PendingKeys = keySequenceName(p.pendingKeys)
+	}
+	return s
+}
+
+// Invalidate makes the prompt render again, e.g. when what the toolbar shows has changed.
+// It may be called from any goroutine.
+func (p *Prompt) Invalidate() {
+	select {
+	case p.invalidateCh <- struct{}{}:
+	default: // already pending
+	}
+}
+
+// startRefresh starts the timer re-rendering the toolbar (see OptionToolbarRefresh);
+// the channel is nil without one.
+func (p *Prompt) startRefresh() (<-chan time.Time, func()) {
+	if p.toolbar == nil || p.toolbarRefresh <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTicker(p.toolbarRefresh)
+	return t.C, t.Stop
+}
+
+// layoutToolbar lays out the toolbar on the row below everything else, clipped to the width.
+func (r *Render) layoutToolbar(f *frame, text StyledText) {
+	y := Row(len(f.rows))
+	row := f.row(y)
+	style := r.theme.Style(RoleToolbar).normalized()
+	for x := range row {
+		row[x] = cell{r: ' ', style: style}
+	}
+
+	walkStyledText(Coord{0, y}, f.width, text, style, func(at Coord, r rune, w Column, style Style) {
+		if at.Y == y {
+			f.put(at, r, w, style)
+		}
+	})
+}
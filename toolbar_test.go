@@ -0,0 +1,46 @@
+package prompt_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prompt "github.com/tatsujin/go-prompt"
+	"github.com/tatsujin/go-prompt/prompttest"
+)
+
+func TestToolbarUpdates(t *testing.T) {
+	scenarioTable := []struct {
+		name    string
+		refresh time.Duration
+		update  func(p *prompt.Prompt)
+	}{
+		{name: "invalidate", update: func(p *prompt.Prompt) { p.Invalidate() }},
+		{name: "output", update: func(p *prompt.Prompt) { p.OutputAsync("hello") }},
+		{name: "refresh", refresh: 10 * time.Millisecond, update: func(*prompt.Prompt) {}},
+	}
+
+	for _, s := range scenarioTable {
+		var n int32
+		term := prompttest.NewTerminal(40, 8)
+		p := prompt.New(func(string) {}, goldenCompleter, append(term.Options(),
+			prompt.OptionToolbar(func(*prompt.Document, prompt.ToolbarState) prompt.StyledText {
+				return prompt.StyledText{{Text: fmt.Sprintf("count %d", atomic.LoadInt32(&n))}}
+			}),
+			prompt.OptionToolbarRefresh(s.refresh),
+		)...)
+		stop := startPrompt(t, p)
+
+		if !term.Screen.WaitForText("count 0", time.Second) {
+			t.Errorf("%s: Should show %#v, but got\n%s", s.name, "count 0", term.Screen.Snapshot())
+		}
+		atomic.StoreInt32(&n, 1)
+		s.update(p)
+		if !term.Screen.WaitForText("count 1", time.Second) {
+			t.Errorf("%s: Should show %#v, but got\n%s", s.name, "count 1", term.Screen.Snapshot())
+		}
+
+		stop()
+	}
+}
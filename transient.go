@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// Transient is how an input is left on the screen once it's been accepted or cancelled (see OptionTransientPrompt).
+type Transient struct {
+	Prefix             StyledText // before the first line
+	ContinuationPrefix StyledText // before the other lines
+	Style              Style      // of the text; nil colors are the input colors
+}
+
+// TransientPrompt returns how to show 'doc' once it's been accepted (or cancelled);
+// if 'ok' is false, it's left as the prompt rendered it.
+type TransientPrompt func(doc *Document, cancelled bool) (t Transient, ok bool)
+
+// layoutTransient lays out 'doc' as an accepted input, with the prefixes and style of 't'.
+func (r *Render) layoutTransient(f *frame, doc *Document, t Transient) {
+	prefixStyle := r.theme.Style(RolePrefix)
+	inputStyle := t.Style.over(r.theme.Style(RoleInput))
+
+	prefix := func(doc *Document, row Row) StyledText {
+		if row == 0 {
+			return t.Prefix
+		}
+		return t.ContinuationPrefix
+	}
+	l := layoutText(doc, f.width, prefix)
+	for row, line := range l.lines {
+		f.writeStyled(line.home, prefix(doc, Row(row)), prefixStyle)
+	}
+	for i, ch := range doc.text {
+		if w := Column(runewidth.RuneWidth(ch)); w > 0 && w <= f.width {
+			f.put(l.runes[i], ch, w, inputStyle)
+		}
+	}
+	f.row(l.rows() - 1)
+}